@@ -0,0 +1,234 @@
+// Command resthttp sends requests with the resthttp client from the command
+// line:
+//
+//	resthttp get -base-url https://api.example.com -q expand=author posts/1
+//	resthttp post -json '{"title":"x"}' posts
+//	resthttp download -o report.csv reports/2024.csv
+//	resthttp upload -file ./photo.jpg -F caption=beach images
+//
+// The base URL, credentials and other settings default to the RESTHTTP_*
+// environment variables understood by resthttp.ConfigFromEnv.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"time"
+
+	"rest/resthttp"
+)
+
+const usage = `usage: resthttp <command> [flags] <path>
+
+commands:
+  get, head, post, put, patch,
+  delete                          send a request and print the response body
+  download                        save a file, see -o
+  upload                          upload the file given with -file
+
+Run "resthttp <command> -h" for the flags of a command.
+`
+
+// listFlag collects repeated flags such as -H.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+type options struct {
+	baseURL  string
+	user     string
+	password string
+	token    string
+	headers  listFlag
+	query    listFlag
+	fields   listFlag
+	body     string
+	output   string
+	file     string
+	retries  int
+	timeout  time.Duration
+	insecure bool
+	verbose  bool
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "help" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := strings.ToLower(os.Args[1])
+
+	var opts options
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	flags.StringVar(&opts.baseURL, "base-url", "", "base URL, defaults to $RESTHTTP_BASE_URL")
+	flags.StringVar(&opts.user, "user", "", "user for basic auth")
+	flags.StringVar(&opts.password, "password", "", "password for basic auth")
+	flags.StringVar(&opts.token, "token", "", "bearer token")
+	flags.Var(&opts.headers, "H", "request header as Name: value, repeatable")
+	flags.Var(&opts.query, "q", "query parameter as name=value, repeatable")
+	flags.StringVar(&opts.body, "json", "", "JSON request body, or @file to read it from a file")
+	flags.StringVar(&opts.output, "o", "", "write the response body to this file")
+	flags.StringVar(&opts.file, "file", "", "file to upload")
+	flags.Var(&opts.fields, "F", "form field sent along with -file as name=value, repeatable")
+	flags.IntVar(&opts.retries, "retries", 0, "retry failed idempotent requests this many times")
+	flags.DurationVar(&opts.timeout, "timeout", 0, "request timeout")
+	flags.BoolVar(&opts.insecure, "insecure", false, "skip TLS certificate verification")
+	flags.BoolVar(&opts.verbose, "v", false, "print the status and response headers to stderr")
+	flags.Parse(os.Args[2:])
+
+	if flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := run(command, flags.Arg(0), &opts); err != nil {
+		fmt.Fprintln(os.Stderr, "resthttp:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string, target string, opts *options) error {
+	client, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	for _, pair := range opts.query {
+		name, value, _ := strings.Cut(pair, "=")
+		query.Add(name, value)
+	}
+	var requestOpts []resthttp.RequestOption
+	for _, header := range opts.headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return fmt.Errorf("invalid header %q, expected Name: value", header)
+		}
+		requestOpts = append(requestOpts, resthttp.WithRequestHeader(strings.TrimSpace(name), strings.TrimSpace(value)))
+	}
+	if opts.timeout > 0 {
+		requestOpts = append(requestOpts, resthttp.WithTimeout(opts.timeout))
+	}
+
+	container, resource := path.Split(strings.Trim(target, "/"))
+	switch command {
+	case "download":
+		return client.DownloadFile(container, resource, opts.output, "", query, requestOpts...)
+	case "upload":
+		if opts.file == "" {
+			return errors.New("upload needs -file")
+		}
+		file, err := os.Open(opts.file)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		fields := url.Values{}
+		for _, pair := range opts.fields {
+			name, value, _ := strings.Cut(pair, "=")
+			fields.Add(name, value)
+		}
+		for name, values := range query {
+			for _, value := range values {
+				requestOpts = append(requestOpts, resthttp.WithQuery(name, value))
+			}
+		}
+		body, err := client.UploadFile(container, resource, fields, "", file, requestOpts...)
+		if err != nil {
+			return err
+		}
+		return output(body, opts.output)
+	case "get", "post", "put", "patch", "delete", "head":
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	req := client.NewRequest().Path(target, nil).QueryValues(query).Option(requestOpts...)
+	if opts.body != "" {
+		data := []byte(opts.body)
+		if strings.HasPrefix(opts.body, "@") {
+			if data, err = ioutil.ReadFile(opts.body[1:]); err != nil {
+				return err
+			}
+		}
+		if !json.Valid(data) {
+			return errors.New("request body is not valid JSON")
+		}
+		req.Body(data, "application/json")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	resp, err := req.Do(ctx, command)
+	if err != nil {
+		return err
+	}
+	if opts.verbose {
+		fmt.Fprintln(os.Stderr, resp.Status)
+		for name, values := range resp.Header {
+			for _, value := range values {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", name, value)
+			}
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+	if err := output(resp.Body, opts.output); err != nil {
+		return err
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("server answered %s", resp.Status)
+	}
+	return nil
+}
+
+func newClient(opts *options) (*resthttp.RestHttp, error) {
+	cfg, err := resthttp.ConfigFromEnv("")
+	if err != nil {
+		return nil, err
+	}
+	if opts.baseURL != "" {
+		cfg.BaseURL = opts.baseURL
+	}
+	if opts.user != "" {
+		cfg.Auth.User, cfg.Auth.Password = opts.user, opts.password
+	}
+	if opts.token != "" {
+		cfg.Auth = resthttp.AuthConfig{Token: opts.token}
+	}
+	if opts.insecure {
+		cfg.TLS.InsecureSkipVerify = true
+	}
+	if opts.retries > 0 {
+		cfg.Retry = &resthttp.RetryConfig{MaxAttempts: opts.retries + 1}
+	}
+	if cfg.BaseURL == "" {
+		return nil, errors.New("no base URL, set -base-url or RESTHTTP_BASE_URL")
+	}
+	return cfg.NewClient()
+}
+
+func output(body []byte, file string) error {
+	if file != "" {
+		return ioutil.WriteFile(file, body, 0644)
+	}
+	_, err := os.Stdout.Write(body)
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		fmt.Println()
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package resthttp
+
+import (
+	"context"
+)
+
+// Future is the pending result of a request started with one of the Async
+// methods. It is safe for concurrent use.
+type Future struct {
+	done     chan struct{}
+	cancel   context.CancelFunc
+	response *Response
+	err      error
+}
+
+// DoAsync starts the request in the background and returns immediately.
+// Cancelling ctx, or calling Cancel on the future, aborts it.
+func (b *RequestBuilder) DoAsync(ctx context.Context, method string) *Future {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &Future{done: make(chan struct{}), cancel: cancel}
+	go func() {
+		defer close(f.done)
+		defer cancel()
+		f.response, f.err = b.Do(ctx, method)
+	}()
+	return f
+}
+
+// GetAsync is Get without waiting for the response.
+func (r *RestHttp) GetAsync(ctx context.Context, template string, params PathParams, opts ...RequestOption) *Future {
+	return r.NewRequest().Path(template, params).Option(opts...).DoAsync(ctx, "GET")
+}
+
+// PostAsync posts body encoded as JSON to the path template without waiting
+// for the response.
+func (r *RestHttp) PostAsync(ctx context.Context, template string, params PathParams, body interface{}, opts ...RequestOption) *Future {
+	return r.NewRequest().Path(template, params).JSON(body).Option(opts...).DoAsync(ctx, "POST")
+}
+
+// Done is closed once the request has finished, successfully or not, so
+// futures can be multiplexed in a select.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Result waits for the request to finish and returns its outcome.
+func (f *Future) Result() (*Response, error) {
+	<-f.done
+	return f.response, f.err
+}
+
+// Cancel aborts the request if it is still in flight; Result then returns
+// the cancellation error.
+func (f *Future) Cancel() {
+	f.cancel()
+}
@@ -0,0 +1,168 @@
+package resthttp
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AuthProvider adds credentials to an outgoing request.
+type AuthProvider interface {
+	Authenticate(req *http.Request) error
+}
+
+type BasicAuth struct {
+	User     string
+	Password string
+}
+
+func (a BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.User, a.Password)
+	return nil
+}
+
+// TokenAuth sends "Authorization: <Scheme> <Token>". Scheme defaults to
+// Bearer.
+type TokenAuth struct {
+	Token  string
+	Scheme string
+}
+
+func (a TokenAuth) Authenticate(req *http.Request) error {
+	scheme := a.Scheme
+	if scheme == "" {
+		scheme = "Bearer"
+	}
+	req.Header.Set("Authorization", scheme+" "+a.Token)
+	return nil
+}
+
+// APIKeyLocation says where APIKeyAuth puts the key.
+type APIKeyLocation int
+
+const (
+	APIKeyInHeader APIKeyLocation = iota
+	APIKeyInQuery
+)
+
+// APIKeyAuth sends Value in the header or query parameter Name, e.g.
+// "X-API-Key" or "api_key".
+type APIKeyAuth struct {
+	Name  string
+	Value string
+	In    APIKeyLocation
+}
+
+func (a APIKeyAuth) Authenticate(req *http.Request) error {
+	if a.In == APIKeyInQuery {
+		query := req.URL.Query()
+		query.Set(a.Name, a.Value)
+		req.URL.RawQuery = query.Encode()
+		return nil
+	}
+	req.Header.Set(a.Name, a.Value)
+	return nil
+}
+
+// WithAuthProvider authenticates every request with provider instead of
+// User and Password. Path scopes and per-request overrides still take
+// precedence.
+func WithAuthProvider(provider AuthProvider) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.auth = provider
+	}
+}
+
+// WithBearerToken authenticates every request with
+// "Authorization: Bearer <token>".
+func WithBearerToken(token string) func(*RestHttp) {
+	return WithAuthProvider(TokenAuth{Token: token})
+}
+
+// WithAPIKey authenticates every request with an API key sent in the header
+// or query parameter name. The key is redacted from logs, dumps, History and
+// HAR recordings, but query keys still reach server and proxy access logs,
+// so prefer the header when the API accepts it.
+func WithAPIKey(name string, value string, in APIKeyLocation) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.auth = APIKeyAuth{Name: name, Value: value, In: in}
+		if in == APIKeyInHeader {
+			WithRedactedHeaders(name)(r)
+		} else {
+			WithRedactedQueryParams(name)(r)
+		}
+	}
+}
+
+type pathAuth struct {
+	prefix   string
+	provider AuthProvider
+}
+
+// WithPathAuth uses provider for every request whose path, relative to
+// BaseURL, starts with prefix (e.g. "/admin"). A nil provider sends requests
+// below prefix without credentials. When several prefixes match, the longest
+// one wins.
+func WithPathAuth(prefix string, provider AuthProvider) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.pathAuth = append(r.pathAuth, pathAuth{
+			prefix:   "/" + strings.Trim(prefix, "/"),
+			provider: provider,
+		})
+	}
+}
+
+// scopedAuth finds the path prefix scope covering req, if any.
+func (r *RestHttp) scopedAuth(req *http.Request) (AuthProvider, bool) {
+	if len(r.pathAuth) == 0 {
+		return nil, false
+	}
+
+	basePath := ""
+	if base, err := url.Parse(r.baseURL()); err == nil {
+		basePath = strings.TrimRight(base.Path, "/")
+	}
+	path := req.URL.Path
+	if !strings.HasPrefix(path, basePath) {
+		return nil, false
+	}
+	path = path[len(basePath):]
+
+	var best *pathAuth
+	for i := range r.pathAuth {
+		scope := &r.pathAuth[i]
+		prefix := strings.TrimRight(scope.prefix, "/")
+		if prefix != "" && path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		if best == nil || len(scope.prefix) > len(best.prefix) {
+			best = scope
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best.provider, true
+}
+
+// authFor resolves the credentials used for a request: a per-request
+// override first, then a matching path scope, then the client's
+// AuthProvider, then its User and Password.
+func (r *RestHttp) authFor(req *http.Request, ro *requestOptions) AuthProvider {
+	if ro.noAuth {
+		return nil
+	}
+	if ro.auth != nil {
+		return ro.auth
+	}
+	if provider, ok := r.scopedAuth(req); ok {
+		return provider
+	}
+	if r.auth != nil {
+		return r.auth
+	}
+	if user, password := r.credentials(); user != "" && password != "" {
+		return BasicAuth{User: user, Password: password}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Package backoff provides the retry machinery of resthttp for reuse with
+// work that is not HTTP: exponential backoff with full jitter and a retry
+// loop honouring context cancellation.
+//
+//	err := backoff.Retry(ctx, 5, backoff.Exponential{}, func(attempt int) error {
+//		if err := publish(msg); err != nil {
+//			if isFatal(err) {
+//				return backoff.Permanent(err)
+//			}
+//			return err
+//		}
+//		return nil
+//	})
+package backoff
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+const (
+	DefaultInitial = 100 * time.Millisecond
+	DefaultMax     = 10 * time.Second
+)
+
+// Exponential doubles the delay ceiling with every retry, starting at Initial
+// and capped at Max. The delay itself is drawn uniformly between zero and the
+// ceiling ("full jitter") unless NoJitter is set.
+type Exponential struct {
+	// Initial defaults to DefaultInitial, Max to DefaultMax.
+	Initial  time.Duration
+	Max      time.Duration
+	NoJitter bool
+}
+
+// MaxDelay returns the cap on delays.
+func (e Exponential) MaxDelay() time.Duration {
+	if e.Max <= 0 {
+		return DefaultMax
+	}
+	return e.Max
+}
+
+// Ceiling returns the largest delay before the given retry, counting from 1.
+func (e Exponential) Ceiling(retry int) time.Duration {
+	initial := e.Initial
+	if initial <= 0 {
+		initial = DefaultInitial
+	}
+	max := e.MaxDelay()
+	if retry < 1 {
+		retry = 1
+	}
+	ceiling := initial << uint(retry-1)
+	if ceiling <= 0 || ceiling > max || retry > 62 {
+		ceiling = max
+	}
+	return ceiling
+}
+
+// Delay returns the time to wait before the given retry, counting from 1.
+func (e Exponential) Delay(retry int) time.Duration {
+	ceiling := e.Ceiling(retry)
+	if e.NoJitter {
+		return ceiling
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// Sleep waits for d, returning early with the context's error when ctx is
+// done first.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Retry calls fn up to attempts times, waiting between attempts as b
+// dictates, until fn returns nil or an error wrapped with Permanent. It
+// returns the last error of fn, unwrapped from Permanent, or the context's
+// error when ctx ends while waiting.
+func Retry(ctx context.Context, attempts int, b Exponential, fn func(attempt int) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt >= attempts {
+			return err
+		}
+		if err := Sleep(ctx, b.Delay(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// Permanent marks err as not worth retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,124 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExponentialCeiling(t *testing.T) {
+	tests := []struct {
+		name  string
+		e     Exponential
+		retry int
+		want  time.Duration
+	}{
+		{"default first", Exponential{}, 1, DefaultInitial},
+		{"default third", Exponential{}, 3, 4 * DefaultInitial},
+		{"retry below one", Exponential{}, 0, DefaultInitial},
+		{"default cap", Exponential{}, 10, DefaultMax},
+		{"custom", Exponential{Initial: time.Second, Max: time.Minute}, 4, 8 * time.Second},
+		{"custom cap", Exponential{Initial: time.Second, Max: 5 * time.Second}, 4, 5 * time.Second},
+		{"overflowing shift", Exponential{}, 40, DefaultMax},
+		{"large retry count", Exponential{}, 1000, DefaultMax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.Ceiling(tt.retry); got != tt.want {
+				t.Errorf("Ceiling(%d) = %s, want %s", tt.retry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExponentialDelay(t *testing.T) {
+	fixed := Exponential{Initial: time.Millisecond, Max: time.Second, NoJitter: true}
+	for retry := 1; retry <= 12; retry++ {
+		if got, want := fixed.Delay(retry), fixed.Ceiling(retry); got != want {
+			t.Errorf("NoJitter Delay(%d) = %s, want the ceiling %s", retry, got, want)
+		}
+	}
+
+	jittered := Exponential{Initial: time.Millisecond, Max: time.Second}
+	for retry := 1; retry <= 12; retry++ {
+		ceiling := jittered.Ceiling(retry)
+		for i := 0; i < 50; i++ {
+			if got := jittered.Delay(retry); got < 0 || got > ceiling {
+				t.Fatalf("Delay(%d) = %s, want it within [0, %s]", retry, got, ceiling)
+			}
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errFatal := errors.New("fatal")
+	fast := Exponential{Initial: time.Microsecond, Max: time.Microsecond}
+	tests := []struct {
+		name      string
+		attempts  int
+		fn        func(attempt int) error
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", 3, func(int) error { return nil }, 1, nil},
+		{"success after failures", 5, func(attempt int) error {
+			if attempt < 3 {
+				return errTemporary
+			}
+			return nil
+		}, 3, nil},
+		{"attempts exhausted", 3, func(int) error { return errTemporary }, 3, errTemporary},
+		{"single attempt", 0, func(int) error { return errTemporary }, 1, errTemporary},
+		{"permanent", 5, func(attempt int) error {
+			if attempt == 2 {
+				return Permanent(errFatal)
+			}
+			return errTemporary
+		}, 2, errFatal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Retry(context.Background(), tt.attempts, fast, func(attempt int) error {
+				calls++
+				if attempt != calls {
+					t.Errorf("call %d got attempt %d", calls, attempt)
+				}
+				return tt.fn(attempt)
+			})
+			if err != tt.wantErr {
+				t.Errorf("Retry returned %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) is not nil")
+	}
+}
+
+func TestRetryCanceledWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	slow := Exponential{Initial: time.Hour, Max: time.Hour, NoJitter: true}
+	calls := 0
+	start := time.Now()
+	err := Retry(ctx, 3, slow, func(int) error {
+		calls++
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return errors.New("temporary")
+	})
+	if err != context.Canceled {
+		t.Errorf("Retry returned %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("Retry waited %s after cancellation", elapsed)
+	}
+}
@@ -0,0 +1,125 @@
+package resthttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Batch queues requests and runs them through a bounded pool of workers:
+//
+//	results := client.Batch(ctx).
+//		Get("/users/{id}", resthttp.PathParams{"id": "1"}).
+//		Get("/users/{id}", resthttp.PathParams{"id": "2"}).
+//		Run(8)
+//
+// A Batch is not safe for concurrent use while it is being filled.
+type Batch struct {
+	client *RestHttp
+	ctx    context.Context
+	items  []batchItem
+}
+
+type batchItem struct {
+	method  string
+	request *RequestBuilder
+}
+
+// BatchResult is the outcome of one queued request.
+type BatchResult struct {
+	// Index is the position of the request in the queue.
+	Index    int
+	Method   string
+	Response *Response
+	Err      error
+}
+
+// BatchResults holds one result per queued request, in queue order.
+type BatchResults []BatchResult
+
+// Err joins the errors of the failed requests, or is nil.
+func (results BatchResults) Err() error {
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("request %d: %w", result.Index, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Failed returns the results that carry an error.
+func (results BatchResults) Failed() BatchResults {
+	var failed BatchResults
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Batch starts an empty batch whose requests use ctx.
+func (r *RestHttp) Batch(ctx context.Context) *Batch {
+	return &Batch{client: r, ctx: ctx}
+}
+
+// Get queues a GET of the path template, as with RestHttp.Get.
+func (b *Batch) Get(template string, params PathParams, opts ...RequestOption) *Batch {
+	return b.Add("GET", b.client.NewRequest().Path(template, params).Option(opts...))
+}
+
+// Delete queues a DELETE of the path template, as with RestHttp.Delete.
+func (b *Batch) Delete(template string, params PathParams, opts ...RequestOption) *Batch {
+	return b.Add("DELETE", b.client.NewRequest().Path(template, params).Option(opts...))
+}
+
+// Add queues an arbitrary request built with NewRequest.
+func (b *Batch) Add(method string, request *RequestBuilder) *Batch {
+	b.items = append(b.items, batchItem{method: method, request: request})
+	return b
+}
+
+// Len returns the number of queued requests.
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+// Run executes the queued requests with at most concurrency in flight and
+// waits for all of them. Requests not started when the context is done fail
+// with the context's error.
+func (b *Batch) Run(concurrency int) BatchResults {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(b.items) {
+		concurrency = len(b.items)
+	}
+
+	results := make(BatchResults, len(b.items))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				item := b.items[i]
+				result := BatchResult{Index: i, Method: item.method}
+				if err := b.ctx.Err(); err != nil {
+					result.Err = err
+				} else {
+					result.Response, result.Err = item.request.Do(b.ctx, item.method)
+				}
+				results[i] = result
+			}
+		}()
+	}
+	for i := range b.items {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,99 @@
+package resthttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// Run with go test -run '^$' -bench . -benchmem to compare allocs/op of the
+// request hot path.
+
+var benchItem = map[string]interface{}{
+	"id":    42,
+	"name":  "widget",
+	"tags":  []string{"a", "b", "c"},
+	"price": 9.99,
+}
+
+func benchServer(b *testing.B) *httptest.Server {
+	data, _ := json.Marshal(benchItem)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.Copy(ioutil.Discard, req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	b.Cleanup(srv.Close)
+	return srv
+}
+
+func benchClients(srv *httptest.Server) []struct {
+	name   string
+	client *RestHttp
+} {
+	insecure := NewRestHttp(srv.URL)
+	insecure.VerifySSL = false
+	return []struct {
+		name   string
+		client *RestHttp
+	}{
+		{"verify", NewRestHttp(srv.URL)},
+		{"insecure", insecure},
+	}
+}
+
+func BenchmarkGetRequest(b *testing.B) {
+	srv := benchServer(b)
+	for _, bc := range benchClients(srv) {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := bc.client.GetRequest("items", "42", url.Values{"expand": {"owner"}}, "application/json", false); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPostRequest(b *testing.B) {
+	srv := benchServer(b)
+	params := url.Values{"name": {"widget"}, "price": {"9.99"}}
+	for _, bc := range benchClients(srv) {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := bc.client.PostRequest("items", "", params, "application/json"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecodeJSON(b *testing.B) {
+	srv := benchServer(b)
+	client := NewRestHttp(srv.URL)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resp, err := client.NewRequest().Container("items").Resource("42").AcceptJSON().Get(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var item struct {
+			ID    int      `json:"id"`
+			Name  string   `json:"name"`
+			Tags  []string `json:"tags"`
+			Price float64  `json:"price"`
+		}
+		if err := resp.Decode(&item); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package resthttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBuilderAccept(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header.Values("Accept")
+	}))
+	defer srv.Close()
+
+	r := NewRestHttp(srv.URL)
+	tests := []struct {
+		name    string
+		builder *RequestBuilder
+		want    []string
+	}{
+		{"accept", r.NewRequest().Accept("text/csv"), []string{"text/csv"}},
+		{"replaces accept", r.NewRequest().Accept("text/csv").AcceptJSON(), []string{"application/json"}},
+		{"replaces header", r.NewRequest().Header("Accept", "text/csv").Accept("application/xml"), []string{"application/xml"}},
+		{"header adds", r.NewRequest().Accept("text/csv").Header("Accept", "text/plain"), []string{"text/csv", "text/plain"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.builder.Container("items").Get(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sent Accept %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package resthttp
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SupportBundle writes a zip archive describing the client to w, for
+// attaching to support tickets. It holds the client's configuration with
+// credentials redacted, the recent requests kept by WithHistory, the Stats
+// and a description of the runtime environment.
+func (r *RestHttp) SupportBundle(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	now := time.Now()
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"config.json", r.supportConfig()},
+		{"history.json", r.History()},
+		{"stats.json", r.Stats()},
+		{"environment.json", environment(now)},
+	}
+	for _, file := range files {
+		out, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return fmt.Errorf("could not write support bundle: %s", err)
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return fmt.Errorf("could not write %s to support bundle: %s", file.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("could not write support bundle: %s", err)
+	}
+	return nil
+}
+
+type supportConfig struct {
+	BaseURL     string       `json:"base_url"`
+	BaseHeaders http.Header  `json:"base_headers"`
+	User        string       `json:"user,omitempty"`
+	Password    string       `json:"password,omitempty"`
+	VerifySSL   bool         `json:"verify_ssl"`
+	Timeout     string       `json:"timeout"`
+	LogLevel    LogLevel     `json:"log_level"`
+	UserAgent   string       `json:"user_agent"`
+	Retry       *RetryPolicy `json:"retry,omitempty"`
+	ClockSkew   string       `json:"clock_skew"`
+	Features    []string     `json:"features"`
+}
+
+func (r *RestHttp) supportConfig() supportConfig {
+	cfg := supportConfig{
+		BaseURL:     redactURL(r.baseURL()),
+		BaseHeaders: r.redactHeader(r.baseHeaders()),
+		VerifySSL:   r.VerifySSL,
+		Timeout:     r.timeout().String(),
+		LogLevel:    r.currentLogLevel(),
+		UserAgent:   r.userAgent,
+		Retry:       r.retryPolicy(),
+		ClockSkew:   r.ClockSkew().String(),
+	}
+	if user, password := r.credentials(); password != "" {
+		cfg.User, cfg.Password = user, redacted
+	} else {
+		cfg.User = user
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+
+	features := []struct {
+		name    string
+		enabled bool
+	}{
+		{"cache", r.cache != nil},
+		{"request_compression", r.gzipRequests},
+		{"response_decompression", r.decompress},
+		{"host_pool", r.pool != nil},
+		{"signing", r.signer != nil},
+		{"auth_provider", r.auth != nil},
+		{"custom_transport", r.transport != nil},
+		{"tls_key_log", r.keyLog != nil},
+		{"wire_tap", r.tap != nil},
+		{"har_recorder", r.har != nil},
+		{"metrics", r.metrics != nil},
+		{"history", r.history != nil},
+		{"request_coalescing", r.flights != nil},
+		{"custom_dns", r.dns != nil},
+		{"custom_tls", r.tlsConfig != nil},
+		{"proxy", r.proxy != nil},
+		{"base_url_migration", r.migration != nil},
+		{"idempotency_keys", r.idempotencyKeys},
+		{"tenant_isolation", r.tenants != nil},
+		{"clock_skew_correction", r.correctSkew},
+		{"download_quarantine", r.quarantineDir != ""},
+	}
+	cfg.Features = []string{}
+	for _, feature := range features {
+		if feature.enabled {
+			cfg.Features = append(cfg.Features, feature.name)
+		}
+	}
+	return cfg
+}
+
+type supportEnvironment struct {
+	Time       time.Time         `json:"time"`
+	Library    string            `json:"library"`
+	GoVersion  string            `json:"go_version"`
+	OS         string            `json:"os"`
+	Arch       string            `json:"arch"`
+	CPUs       int               `json:"cpus"`
+	Goroutines int               `json:"goroutines"`
+	Hostname   string            `json:"hostname,omitempty"`
+	Proxy      map[string]string `json:"proxy,omitempty"`
+}
+
+func environment(now time.Time) supportEnvironment {
+	env := supportEnvironment{
+		Time:       now,
+		Library:    "resthttp/" + Version,
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		CPUs:       runtime.NumCPU(),
+		Goroutines: runtime.NumGoroutine(),
+	}
+	env.Hostname, _ = os.Hostname()
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"} {
+		for _, key := range []string{name, strings.ToLower(name)} {
+			if value := os.Getenv(key); value != "" {
+				if env.Proxy == nil {
+					env.Proxy = make(map[string]string)
+				}
+				env.Proxy[key] = redactURL(value)
+			}
+		}
+	}
+	return env
+}
+
+// redactURL hides the password of a URL; anything that does not parse is
+// returned as is.
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.User == nil {
+		return raw
+	}
+	return parsed.Redacted()
+}
@@ -0,0 +1,425 @@
+package resthttp
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a stored response together with what is needed to judge its
+// freshness.
+type CacheEntry struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+	StoredAt   time.Time
+	// Vary holds the request header values the response varies on.
+	Vary map[string]string
+}
+
+// Cache stores responses to GET requests. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+}
+
+// WithCache enables an HTTP cache honoring Cache-Control, Expires, ETag and
+// Last-Modified for GET requests.
+func WithCache(cache Cache) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.cache = cache
+	}
+}
+
+// Default limits of NewMemoryCache.
+const (
+	DefaultCacheEntries = 1024
+	DefaultCacheBytes   = 64 << 20
+)
+
+// MemoryCache keeps entries in memory and evicts the least recently used
+// ones once it holds more than its entry or byte limit.
+type MemoryCache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List
+	size       int64
+	maxEntries int
+	maxBytes   int64
+}
+
+type memoryItem struct {
+	key   string
+	entry *CacheEntry
+	size  int64
+}
+
+func NewMemoryCache() *MemoryCache {
+	return NewMemoryCacheWithLimits(DefaultCacheEntries, DefaultCacheBytes)
+}
+
+// NewMemoryCacheWithLimits returns a MemoryCache holding at most maxEntries
+// entries with at most maxBytes of bodies. Zero disables the respective
+// limit.
+func NewMemoryCacheWithLimits(maxEntries int, maxBytes int64) *MemoryCache {
+	return &MemoryCache{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*memoryItem).entry, true
+}
+
+// Set stores entry, which must not be modified afterwards since it is
+// handed out to concurrent readers.
+func (c *MemoryCache) Set(key string, entry *CacheEntry) {
+	size := int64(len(entry.Body))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxBytes > 0 && size > c.maxBytes {
+		c.remove(key)
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		item := elem.Value.(*memoryItem)
+		c.size += size - item.size
+		item.entry, item.size = entry, size
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.lru.PushFront(&memoryItem{key: key, entry: entry, size: size})
+		c.size += size
+	}
+	for (c.maxEntries > 0 && c.lru.Len() > c.maxEntries) || (c.maxBytes > 0 && c.size > c.maxBytes) {
+		c.remove(c.lru.Back().Value.(*memoryItem).key)
+	}
+}
+
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(key)
+}
+
+// Len reports the number of entries held.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *MemoryCache) remove(key string) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	c.lru.Remove(elem)
+	delete(c.entries, key)
+	c.size -= elem.Value.(*memoryItem).size
+}
+
+// DiskCache keeps one JSON file per entry in Dir, so cached responses survive
+// restarts of the process.
+type DiskCache struct {
+	Dir string
+}
+
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{Dir: dir}, nil
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	entry := &CacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (c *DiskCache) Set(key string, entry *CacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	tmp, err := ioutil.TempFile(c.Dir, "entry-*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	closeErr := tmp.Close()
+	if err != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func (c *DiskCache) Delete(key string) {
+	os.Remove(c.path(key))
+}
+
+func cacheKey(req *http.Request, scopeHeaders []string) string {
+	key := req.Method + " " + req.URL.String()
+	// Responses for different principals must never be mixed up.
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		sum := sha256.Sum256([]byte(auth))
+		key += " " + hex.EncodeToString(sum[:8])
+	}
+	// Neither must those for the scopes of derived clients, see WithHeader.
+	for _, name := range scopeHeaders {
+		sum := sha256.Sum256([]byte(name + ": " + strings.Join(req.Header.Values(name), ",")))
+		key += " " + hex.EncodeToString(sum[:8])
+	}
+	return key
+}
+
+func parseCacheControl(values []string) map[string]string {
+	directives := make(map[string]string)
+	for _, item := range splitHeaderList(values) {
+		name, value, _ := strings.Cut(item, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return directives
+}
+
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusGone:                 true,
+}
+
+func isStorable(req *http.Request, resp *http.Response) bool {
+	if !cacheableStatus[resp.StatusCode] {
+		return false
+	}
+	if _, ok := parseCacheControl(req.Header.Values("Cache-Control"))["no-store"]; ok {
+		return false
+	}
+	cc := parseCacheControl(resp.Header.Values("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if resp.Header.Get("Vary") == "*" {
+		return false
+	}
+	// Without explicit freshness or a validator the entry could never be
+	// used, so there is no point in keeping it.
+	_, hasMaxAge := cc["max-age"]
+	return hasMaxAge || resp.Header.Get("Expires") != "" || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// freshnessLifetime follows RFC 7234 section 4.2.1, including the 10%
+// heuristic for responses that only carry Last-Modified.
+func freshnessLifetime(header http.Header) time.Duration {
+	cc := parseCacheControl(header.Values("Cache-Control"))
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+	if maxAge, ok := cc["max-age"]; ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		return 0
+	}
+
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		date = time.Now()
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+	if lastModified, err := http.ParseTime(header.Get("Last-Modified")); err == nil && date.After(lastModified) {
+		return date.Sub(lastModified) / 10
+	}
+	return 0
+}
+
+func (e *CacheEntry) age(now time.Time) time.Duration {
+	age := now.Sub(e.StoredAt)
+	if seconds, err := strconv.Atoi(e.Header.Get("Age")); err == nil {
+		age += time.Duration(seconds) * time.Second
+	}
+	return age
+}
+
+func (e *CacheEntry) fresh(req *http.Request, now time.Time) bool {
+	reqCC := parseCacheControl(req.Header.Values("Cache-Control"))
+	if _, ok := reqCC["no-cache"]; ok {
+		return false
+	}
+	lifetime := freshnessLifetime(e.Header)
+	if maxAge, ok := reqCC["max-age"]; ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil && time.Duration(seconds)*time.Second < lifetime {
+			lifetime = time.Duration(seconds) * time.Second
+		}
+	}
+	return e.age(now) < lifetime
+}
+
+func (e *CacheEntry) matches(req *http.Request) bool {
+	for name, value := range e.Vary {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *CacheEntry) response(req *http.Request) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(e.age(time.Now())/time.Second)))
+	return &http.Response{
+		Status:        e.Status,
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+func newCacheEntry(req *http.Request, resp *http.Response, body []byte) *CacheEntry {
+	entry := &CacheEntry{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		StoredAt:   time.Now(),
+	}
+	for _, name := range splitHeaderList(resp.Header.Values("Vary")) {
+		if entry.Vary == nil {
+			entry.Vary = make(map[string]string)
+		}
+		entry.Vary[http.CanonicalHeaderKey(name)] = req.Header.Get(name)
+	}
+	return entry
+}
+
+// unmergedHeaders describe the 304 message rather than the stored
+// response, so they are not copied into it (RFC 9111 section 3.2).
+var unmergedHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+}
+
+// cachedDo serves GET requests from the cache when possible, revalidates
+// stale entries with conditional requests and stores cacheable responses.
+func (r *RestHttp) cachedDo(req *http.Request, ro *requestOptions) (*http.Response, error) {
+	key := cacheKey(req, r.scopeHeaders)
+	entry, ok := r.cache.Get(key)
+	if ok && !entry.matches(req) {
+		entry, ok = nil, false
+	}
+
+	if ok && entry.fresh(req, time.Now()) {
+		r.count(func(s *clientStats) { s.cacheHits.Add(1) })
+		return entry.response(req), nil
+	}
+
+	// Callers doing their own conditional requests get the raw result.
+	userConditional := req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+	revalidating := false
+	if ok && !userConditional {
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+			revalidating = true
+		}
+		if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+			revalidating = true
+		}
+	}
+
+	resp, err := r.send(req, ro)
+	if err != nil {
+		return nil, err
+	}
+
+	if revalidating && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		// The stored entry may be in use by concurrent readers, so the
+		// refreshed headers go into a copy.
+		refreshed := *entry
+		refreshed.Header = entry.Header.Clone()
+		for name, values := range resp.Header {
+			if !unmergedHeaders[name] {
+				refreshed.Header[name] = values
+			}
+		}
+		refreshed.StoredAt = time.Now()
+		entry = &refreshed
+		r.cache.Set(key, entry)
+		r.count(func(s *clientStats) {
+			s.cacheHits.Add(1)
+			s.cacheRevalidations.Add(1)
+		})
+		return entry.response(req), nil
+	}
+
+	r.count(func(s *clientStats) { s.cacheMisses.Add(1) })
+
+	if !isStorable(req, resp) {
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+			r.cache.Delete(key)
+		}
+		return resp, nil
+	}
+
+	body, err := r.readBody(resp, ro)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.cache.Set(key, newCacheEntry(req, resp, body))
+	return resp, nil
+}
@@ -0,0 +1,66 @@
+package resthttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestCacheRevalidationHeaders(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header: http.Header{
+				"Etag":           {`"v1"`},
+				"Cache-Control":  {"max-age=0"},
+				"Content-Length": {"5"},
+				"X-Version":      {"1"},
+			},
+			Body:    io.NopCloser(strings.NewReader("hello")),
+			Request: req,
+		}
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			resp.StatusCode = http.StatusNotModified
+			resp.Header = http.Header{
+				"Etag":              {`"v1"`},
+				"Content-Length":    {"0"},
+				"Transfer-Encoding": {"chunked"},
+				"X-Version":         {"2"},
+			}
+			resp.Body = http.NoBody
+		}
+		return resp, nil
+	})
+
+	r := NewRestHttp("http://api.test", WithTransport(transport), WithCache(NewMemoryCache()))
+	for i := 0; i < 3; i++ {
+		resp, err := r.NewRequest().Container("items").Get(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(resp.Body) != "hello" {
+			t.Fatalf("got body %q, want hello", resp.Body)
+		}
+		if i == 0 {
+			continue
+		}
+		if got := resp.Header.Get("Content-Length"); got != "5" {
+			t.Errorf("request %d: Content-Length %q, want 5 like the cached body", i, got)
+		}
+		if got := resp.Header.Get("Transfer-Encoding"); got != "" {
+			t.Errorf("request %d: Transfer-Encoding %q copied from the 304", i, got)
+		}
+		if got := resp.Header.Get("X-Version"); got != "2" {
+			t.Errorf("request %d: X-Version %q, want the refreshed 2", i, got)
+		}
+	}
+	if n := r.Stats().CacheRevalidations; n != 2 {
+		t.Errorf("got %d revalidations, want 2", n)
+	}
+}
@@ -0,0 +1,690 @@
+package resthttp
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// CBORCodec encodes values as CBOR (RFC 8949) the way encoding/json encodes
+// them as JSON: struct fields use their `cbor` tag, or else their `json` tag,
+// map keys are sorted, and decoding into an interface{} yields int64, uint64,
+// float64, string, []byte, []interface{} and map[string]interface{} values.
+// Tags are accepted and ignored when decoding.
+type CBORCodec struct{}
+
+func (CBORCodec) ContentType() string { return "application/cbor" }
+
+func (CBORCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := cborEncode(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, fmt.Errorf("cbor codec: %s", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (CBORCodec) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cbor codec: Unmarshal needs a non-nil pointer, got %T", v)
+	}
+	d := cborDecoder{data: data}
+	item, err := d.item(0)
+	if err != nil {
+		return fmt.Errorf("cbor codec: %s", err)
+	}
+	if d.off != len(data) {
+		return fmt.Errorf("cbor codec: %d trailing bytes", len(data)-d.off)
+	}
+	if err := cborAssign(rv.Elem(), item); err != nil {
+		return fmt.Errorf("cbor codec: %s", err)
+	}
+	return nil
+}
+
+const (
+	cborUint   = 0 << 5
+	cborNegint = 1 << 5
+	cborBytes  = 2 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+	cborSimple = 7 << 5
+
+	cborFalse = cborSimple | 20
+	cborTrue  = cborSimple | 21
+	cborNull  = cborSimple | 22
+	cborBreak = cborSimple | 31
+
+	// cborMaxDepth bounds the nesting of arrays, maps and tags.
+	cborMaxDepth = 512
+)
+
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(major | 27)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+func cborEncode(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteByte(cborNull)
+		return nil
+	}
+	if v.Type().Implements(textMarshalerType) && !(v.Kind() == reflect.Ptr && v.IsNil()) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		cborHead(buf, cborText, uint64(len(text)))
+		buf.Write(text)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteByte(cborNull)
+			return nil
+		}
+		return cborEncode(buf, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(cborTrue)
+		} else {
+			buf.WriteByte(cborFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.Int(); n < 0 {
+			cborHead(buf, cborNegint, uint64(-(n + 1)))
+		} else {
+			cborHead(buf, cborUint, uint64(n))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		cborHead(buf, cborUint, v.Uint())
+	case reflect.Float32:
+		buf.WriteByte(cborSimple | 26)
+		binary.Write(buf, binary.BigEndian, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		buf.WriteByte(cborSimple | 27)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v.Float()))
+	case reflect.String:
+		cborHead(buf, cborText, uint64(v.Len()))
+		buf.WriteString(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteByte(cborNull)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			cborHead(buf, cborBytes, uint64(v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				buf.WriteByte(byte(v.Index(i).Uint()))
+			}
+			return nil
+		}
+		cborHead(buf, cborArray, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := cborEncode(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteByte(cborNull)
+			return nil
+		}
+		return cborEncodeMap(buf, v)
+	case reflect.Struct:
+		fields := cborFields(v.Type())
+		var present []cborField
+		for _, f := range fields {
+			fv := v.FieldByIndex(f.index)
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			present = append(present, f)
+		}
+		cborHead(buf, cborMap, uint64(len(present)))
+		for _, f := range present {
+			cborHead(buf, cborText, uint64(len(f.name)))
+			buf.WriteString(f.name)
+			if err := cborEncode(buf, v.FieldByIndex(f.index)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// cborEncodeMap writes the entries of v sorted by their encoded keys, the
+// deterministic order of RFC 8949 section 4.2.1.
+func cborEncodeMap(buf *bytes.Buffer, v reflect.Value) error {
+	type entry struct {
+		key   []byte
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		var key bytes.Buffer
+		if err := cborEncode(&key, iter.Key()); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key.Bytes(), iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+
+	cborHead(buf, cborMap, uint64(len(entries)))
+	for _, e := range entries {
+		buf.Write(e.key)
+		if err := cborEncode(buf, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type cborField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// cborFields lists the encoded fields of t, flattening untagged embedded
+// structs as encoding/json does.
+func cborFields(t reflect.Type) []cborField {
+	var fields []cborField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("cbor")
+		if !ok {
+			tag = sf.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && sf.Type.Kind() != reflect.Ptr {
+				for _, f := range cborFields(ft) {
+					f.index = append([]int{i}, f.index...)
+					fields = append(fields, f)
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, cborField{name: name, index: []int{i}, omitEmpty: opts == "omitempty"})
+	}
+	return fields
+}
+
+// cborPair is a decoded map entry; keys need not be strings.
+type cborPair struct {
+	key, value interface{}
+}
+
+// cborDecoder parses one data item into int64, uint64, float64, bool, nil,
+// string, []byte, []interface{} or []cborPair.
+type cborDecoder struct {
+	data []byte
+	off  int
+}
+
+var errCBORShort = errors.New("unexpected end of data")
+
+func (d *cborDecoder) byte() (byte, error) {
+	if d.off >= len(d.data) {
+		return 0, errCBORShort
+	}
+	b := d.data[d.off]
+	d.off++
+	return b, nil
+}
+
+func (d *cborDecoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.off) {
+		return nil, errCBORShort
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// argument reads the argument of an initial byte. indefinite reports the
+// indefinite length marker.
+func (d *cborDecoder) argument(info byte) (n uint64, indefinite bool, err error) {
+	switch {
+	case info < 24:
+		return uint64(info), false, nil
+	case info == 31:
+		return 0, true, nil
+	case info > 27:
+		return 0, false, fmt.Errorf("reserved additional information %d", info)
+	}
+	b, err := d.bytes(1 << (info - 24))
+	if err != nil {
+		return 0, false, err
+	}
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, false, nil
+}
+
+func (d *cborDecoder) atBreak() bool {
+	if d.off < len(d.data) && d.data[d.off] == cborBreak {
+		d.off++
+		return true
+	}
+	return false
+}
+
+func (d *cborDecoder) item(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.New("data nested too deeply")
+	}
+	initial, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	major, info := initial&0xe0, initial&0x1f
+	if major == cborSimple {
+		return d.simple(info)
+	}
+	n, indefinite, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+	if indefinite && (major == cborUint || major == cborNegint || major == cborTag) {
+		return nil, fmt.Errorf("indefinite length for major type %d", major>>5)
+	}
+
+	switch major {
+	case cborUint:
+		if n <= math.MaxInt64 {
+			return int64(n), nil
+		}
+		return n, nil
+	case cborNegint:
+		if n > math.MaxInt64 {
+			return nil, errors.New("negative integer overflows int64")
+		}
+		return -1 - int64(n), nil
+	case cborBytes, cborText:
+		var b []byte
+		if indefinite {
+			b, err = d.chunks(major)
+		} else {
+			b, err = d.bytes(n)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if major == cborText {
+			return string(b), nil
+		}
+		return append([]byte(nil), b...), nil
+	case cborArray:
+		items := []interface{}{}
+		for i := 0; indefinite || uint64(i) < n; i++ {
+			if indefinite && d.atBreak() {
+				break
+			}
+			item, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case cborMap:
+		pairs := []cborPair{}
+		for i := 0; indefinite || uint64(i) < n; i++ {
+			if indefinite && d.atBreak() {
+				break
+			}
+			key, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, cborPair{key, value})
+		}
+		return pairs, nil
+	default: // cborTag
+		return d.item(depth + 1)
+	}
+}
+
+// chunks joins the definite length chunks of an indefinite length string.
+func (d *cborDecoder) chunks(major byte) ([]byte, error) {
+	var joined []byte
+	for !d.atBreak() {
+		initial, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if initial&0xe0 != major || initial&0x1f == 31 {
+			return nil, errors.New("invalid chunk in indefinite length string")
+		}
+		n, _, err := d.argument(initial & 0x1f)
+		if err != nil {
+			return nil, err
+		}
+		chunk, err := d.bytes(n)
+		if err != nil {
+			return nil, err
+		}
+		joined = append(joined, chunk...)
+	}
+	return joined, nil
+}
+
+func (d *cborDecoder) simple(info byte) (interface{}, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23: // null, undefined
+		return nil, nil
+	case 25:
+		b, err := d.bytes(2)
+		if err != nil {
+			return nil, err
+		}
+		return halfToFloat(binary.BigEndian.Uint16(b)), nil
+	case 26:
+		b, err := d.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 27:
+		b, err := d.bytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case 31:
+		return nil, errors.New("unexpected break")
+	}
+	return nil, fmt.Errorf("unsupported simple value %d", info)
+}
+
+// halfToFloat converts an IEEE 754 half precision float.
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// cborAssign stores a decoded item in v.
+func cborAssign(v reflect.Value, item interface{}) error {
+	if item == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return cborAssign(v.Elem(), item)
+	}
+	if text, ok := item.(string); ok && v.CanAddr() && reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+
+	mismatch := fmt.Errorf("cannot decode %T into %s", item, v.Type())
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return mismatch
+		}
+		generic, err := cborGeneric(item)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(generic))
+	case reflect.Bool:
+		b, ok := item.(bool)
+		if !ok {
+			return mismatch
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := item.(int64)
+		if !ok || v.OverflowInt(n) {
+			return mismatch
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		switch i := item.(type) {
+		case int64:
+			if i < 0 {
+				return mismatch
+			}
+			n = uint64(i)
+		case uint64:
+			n = i
+		default:
+			return mismatch
+		}
+		if v.OverflowUint(n) {
+			return mismatch
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		switch i := item.(type) {
+		case float64:
+			v.SetFloat(i)
+		case int64:
+			v.SetFloat(float64(i))
+		case uint64:
+			v.SetFloat(float64(i))
+		default:
+			return mismatch
+		}
+	case reflect.String:
+		s, ok := item.(string)
+		if !ok {
+			return mismatch
+		}
+		v.SetString(s)
+	case reflect.Slice:
+		if b, ok := item.([]byte); ok && v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(b)
+			return nil
+		}
+		items, ok := item.([]interface{})
+		if !ok {
+			return mismatch
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, elem := range items {
+			if err := cborAssign(s.Index(i), elem); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Array:
+		if b, ok := item.([]byte); ok && v.Type().Elem().Kind() == reflect.Uint8 && len(b) == v.Len() {
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+		items, ok := item.([]interface{})
+		if !ok || len(items) != v.Len() {
+			return mismatch
+		}
+		for i, elem := range items {
+			if err := cborAssign(v.Index(i), elem); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		pairs, ok := item.([]cborPair)
+		if !ok {
+			return mismatch
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(pairs))
+		for _, p := range pairs {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := cborAssign(key, p.key); err != nil {
+				return err
+			}
+			// A byte string or array key stored in an interface{} cannot be
+			// hashed.
+			if !key.Comparable() {
+				return fmt.Errorf("cannot use %T as a key of %s", p.key, v.Type())
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := cborAssign(value, p.value); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		pairs, ok := item.([]cborPair)
+		if !ok {
+			return mismatch
+		}
+		fields := cborFields(v.Type())
+		for _, p := range pairs {
+			name, ok := p.key.(string)
+			if !ok {
+				continue
+			}
+			f, ok := cborFieldNamed(fields, name)
+			if !ok {
+				continue
+			}
+			if err := cborAssign(v.FieldByIndex(f.index), p.value); err != nil {
+				return fmt.Errorf("field %s: %s", f.name, err)
+			}
+		}
+	default:
+		return mismatch
+	}
+	return nil
+}
+
+// cborFieldNamed prefers an exact match, then a case-insensitive one, as
+// encoding/json does.
+func cborFieldNamed(fields []cborField, name string) (cborField, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return cborField{}, false
+}
+
+// cborGeneric converts decoded maps for storage in an interface{}: maps with
+// only string keys become map[string]interface{}, others
+// map[interface{}]interface{}.
+func cborGeneric(item interface{}) (interface{}, error) {
+	switch i := item.(type) {
+	case []interface{}:
+		for n, elem := range i {
+			v, err := cborGeneric(elem)
+			if err != nil {
+				return nil, err
+			}
+			i[n] = v
+		}
+		return i, nil
+	case []cborPair:
+		strs := make(map[string]interface{}, len(i))
+		for _, p := range i {
+			if _, ok := p.key.(string); !ok {
+				return cborGenericMap(i)
+			}
+		}
+		for _, p := range i {
+			v, err := cborGeneric(p.value)
+			if err != nil {
+				return nil, err
+			}
+			strs[p.key.(string)] = v
+		}
+		return strs, nil
+	}
+	return item, nil
+}
+
+func cborGenericMap(pairs []cborPair) (interface{}, error) {
+	m := make(map[interface{}]interface{}, len(pairs))
+	for _, p := range pairs {
+		switch p.key.(type) {
+		case string, int64, uint64, float64, bool:
+		default:
+			return nil, fmt.Errorf("unsupported map key %T", p.key)
+		}
+		v, err := cborGeneric(p.value)
+		if err != nil {
+			return nil, err
+		}
+		m[p.key] = v
+	}
+	return m, nil
+}
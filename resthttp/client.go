@@ -0,0 +1,66 @@
+package resthttp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// Getter, Poster and the other single-verb interfaces let code depend on
+// just the calls it makes; Client combines them. *RestHttp implements all of
+// them, and resthttptest provides a programmable fake.
+
+type Getter interface {
+	GetRequest(container string, resource string, queryItems url.Values, accept string, toLower bool, opts ...RequestOption) ([]byte, error)
+}
+
+type Poster interface {
+	PostRequest(container string, resource string, params url.Values, accept string, opts ...RequestOption) ([]byte, error)
+}
+
+type Putter interface {
+	PutRequest(container string, resource string, params url.Values, accept string, opts ...RequestOption) ([]byte, error)
+}
+
+type Deleter interface {
+	DeleteRequest(container string, resource string, queryItems url.Values, accept string, opts ...RequestOption) ([]byte, error)
+}
+
+type Downloader interface {
+	DownloadFile(container string, resource string, savePath string, accept string, queryItems url.Values, opts ...RequestOption) error
+}
+
+type Uploader interface {
+	UploadFile(container string, resource string, params url.Values, contentType string, file *os.File, opts ...RequestOption) ([]byte, error)
+	UploadFileMP(container string, srcFilePath string, dstName string, contentType string, opts ...RequestOption) ([]byte, error)
+	UploadFiles(container string, srcDstMap map[string]string, contentType string, opts ...RequestOption) ([]byte, error)
+}
+
+type Client interface {
+	Getter
+	Poster
+	Putter
+	Deleter
+	Downloader
+	Uploader
+
+	MakeURL(container string, resource string, queryItems url.Values) string
+	HeadRequest(container string, resource string, opts ...RequestOption) (int, error)
+	HeadRequestFull(container string, resource string, opts ...RequestOption) (int, http.Header, error)
+	OptionsRequest(container string, resource string, opts ...RequestOption) (*OptionsResult, error)
+	Get(ctx context.Context, template string, params PathParams, opts ...RequestOption) (*Response, error)
+	Delete(ctx context.Context, template string, params PathParams, opts ...RequestOption) (*Response, error)
+	GraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}, opts ...RequestOption) error
+	NewRequest() *RequestBuilder
+}
+
+var _ Client = (*RestHttp)(nil)
+
+// WithTransport sends all requests through transport, for example a test
+// double or an instrumented RoundTripper. VerifySSL is not applied to it.
+func WithTransport(transport http.RoundTripper) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.transport = transport
+	}
+}
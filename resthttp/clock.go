@@ -0,0 +1,65 @@
+package resthttp
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// minSkewCorrection is the smallest skew corrected for; Date headers only
+// have a resolution of one second.
+const minSkewCorrection = 2 * time.Second
+
+// WithClockSkewCorrection makes the client sign requests with the server's
+// time, as estimated from the Date headers of its responses, instead of the
+// local clock. This keeps signatures valid from hosts whose clock is off.
+func WithClockSkewCorrection() func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.correctSkew = true
+	}
+}
+
+// ClockSkew returns the server's clock minus the local clock as seen in the
+// Date header of the latest response, or zero before any was received.
+func (r *RestHttp) ClockSkew() time.Duration {
+	return time.Duration(r.skew.Load())
+}
+
+// ServerTime returns the current time according to the server's clock.
+func (r *RestHttp) ServerTime() time.Time {
+	return time.Now().Add(r.ClockSkew())
+}
+
+type signingTimeKey struct{}
+
+// SigningTime returns the time a RequestSigner should put into req: the
+// server's time when the client corrects clock skew, the local time
+// otherwise.
+func SigningTime(req *http.Request) time.Time {
+	if t, ok := req.Context().Value(signingTimeKey{}).(time.Time); ok {
+		return t
+	}
+	return time.Now()
+}
+
+// withSigningTime prepares req for the signer.
+func (r *RestHttp) withSigningTime(req *http.Request) *http.Request {
+	skew := r.ClockSkew()
+	if !r.correctSkew || (skew < minSkewCorrection && skew > -minSkewCorrection) {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), signingTimeKey{}, time.Now().Add(skew)))
+}
+
+// observeClock records the skew shown by resp, which answered a request sent
+// at sent. A large skew is logged once, since it breaks signed requests.
+func (r *RestHttp) observeClock(resp *http.Response, sent time.Time) {
+	skew, ok := clockSkew(resp.Header, sent, time.Now())
+	if !ok {
+		return
+	}
+	r.skew.Store(int64(skew))
+	if (skew > MaxClockSkew || skew < -MaxClockSkew) && r.skewWarned.CompareAndSwap(false, true) {
+		r.log(LogError, "local clock differs from the server's", "skew", skew, "host", resp.Request.URL.Host)
+	}
+}
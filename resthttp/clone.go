@@ -0,0 +1,116 @@
+package resthttp
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// Clone returns an independent copy of the client that shares its
+// transport and connection pool, cache, stats and history. Configuration
+// changed on the copy, through the Set methods or further options, does not
+// affect the original.
+func (r *RestHttp) Clone() *RestHttp {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c := &RestHttp{
+		BaseURL:      r.BaseURL,
+		BaseHeaders:  r.BaseHeaders.Clone(),
+		User:         r.User,
+		Password:     r.Password,
+		VerifySSL:    r.VerifySSL,
+		DebugPrint:   r.DebugPrint,
+		Timeout:      r.Timeout,
+		PollInterval: r.PollInterval,
+		config:       r.config,
+	}
+	if c.BaseHeaders == nil {
+		c.BaseHeaders = make(http.Header)
+	}
+
+	// Appending to a shared slice or writing to a shared map must not reach
+	// the original.
+	c.responseHooks = clip(c.responseHooks)
+	c.pathAuth = clip(c.pathAuth)
+	c.codecs = clip(c.codecs)
+	c.downloadChecks = clip(c.downloadChecks)
+	c.redactHeaders = clip(c.redactHeaders)
+	c.redactQuery = clip(c.redactQuery)
+	c.scopeHeaders = clip(c.scopeHeaders)
+	if r.decoders != nil {
+		c.decoders = make(map[string]ContentDecoder, len(r.decoders))
+		for name, decoder := range r.decoders {
+			c.decoders[name] = decoder
+		}
+	}
+	if r.templateFuncs != nil {
+		c.templateFuncs = make(template.FuncMap, len(r.templateFuncs))
+		for name, fn := range r.templateFuncs {
+			c.templateFuncs[name] = fn
+		}
+	}
+	c.skew.Store(r.skew.Load())
+	return c
+}
+
+// With returns a clone of the client with options applied:
+//
+//	tenant := client.With(resthttp.WithAuthProvider(resthttp.TokenAuth{Token: token}))
+//
+// The clone keeps sharing the connection pool unless the options change how
+// connections are made, like WithTLSConfig or WithTransportSettings.
+func (r *RestHttp) With(options ...func(*RestHttp)) *RestHttp {
+	c := r.Clone()
+	for _, option := range options {
+		option(c)
+	}
+	if c.VerifySSL != r.VerifySSL || c.dns != r.dns || c.transportSettings != r.transportSettings || c.tlsConfig != r.tlsConfig || c.proxy != r.proxy || !sameValue(c.keyLog, r.keyLog) {
+		c.transports = &sharedTransport{}
+	}
+	return c
+}
+
+// WithHeader returns a clone of the client that sends header key with every
+// request, such as a tenant ID. Cached responses are kept apart per value.
+func (r *RestHttp) WithHeader(key string, value string) *RestHttp {
+	c := r.Clone()
+	key = http.CanonicalHeaderKey(key)
+	c.BaseHeaders.Set(key, value)
+	for _, name := range c.scopeHeaders {
+		if name == key {
+			return c
+		}
+	}
+	c.scopeHeaders = append(c.scopeHeaders, key)
+	return c
+}
+
+// WithBasePath returns a clone of the client whose base URL is extended by
+// path, such as "/v2".
+func (r *RestHttp) WithBasePath(path string) *RestHttp {
+	c := r.Clone()
+	path = strings.Trim(path, "/")
+	if path != "" {
+		c.BaseURL = strings.TrimRight(c.BaseURL, "/") + "/" + path
+	}
+	return c
+}
+
+// clip limits the capacity of s to its length, so that appending copies.
+func clip[S ~[]E, E any](s S) S {
+	return s[:len(s):len(s)]
+}
+
+// sameValue compares interface values without panicking on uncomparable
+// dynamic types, which are reported as different.
+func sameValue(a interface{}, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
@@ -0,0 +1,109 @@
+package resthttp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// WithRequestCoalescing shares one upstream request between concurrent GETs
+// of the same URL with the same headers: while a request is in flight,
+// identical requests wait for it and receive a copy of its response instead
+// of being sent. This prevents stampedes when many callers miss a cache at
+// once.
+//
+// The shared request runs with the context of the caller that started it;
+// if that caller gives up, the waiting callers get its error. Streaming
+// requests are never coalesced.
+func WithRequestCoalescing() func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.flights = &flightGroup{calls: make(map[string]*flight)}
+	}
+}
+
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is a shared request; the fields are set before done is closed.
+type flight struct {
+	done chan struct{}
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+func (r *RestHttp) coalescable(req *http.Request, ro *requestOptions) bool {
+	return r.flights != nil && req.Method == "GET" && !ro.stream && !ro.keepEncoded
+}
+
+// coalescedDo performs req through roundTrip, or waits for the identical
+// request already in flight.
+func (r *RestHttp) coalescedDo(req *http.Request, ro *requestOptions) (*http.Response, error) {
+	key := flightKey(req)
+	g := r.flights
+
+	g.mu.Lock()
+	if f, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		r.count(func(s *clientStats) { s.coalesced.Add(1) })
+		select {
+		case <-f.done:
+			return f.response(req)
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	f.resp, f.err = r.roundTrip(req, ro)
+	if f.err == nil {
+		f.body, f.err = r.readBody(f.resp, ro)
+		f.resp.Body.Close()
+	}
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(f.done)
+
+	return f.response(req)
+}
+
+// response gives every caller its own copy of the shared response.
+func (f *flight) response(req *http.Request) (*http.Response, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	resp := *f.resp
+	resp.Header = f.resp.Header.Clone()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(f.body))
+	resp.Request = req
+	return &resp, nil
+}
+
+// flightKey identifies requests by method, URL and all headers, hashed so
+// that credentials are not kept in memory longer than needed.
+func flightKey(req *http.Request) string {
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.URL.String() + "\n"))
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			h.Write([]byte(name + ": " + value + "\n"))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,181 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// Codec encodes request bodies and decodes response bodies for one content
+// type. Register codecs with WithCodec; JSON and XML are always available.
+// PostRequest and PutRequest encode their params with the codec matching a
+// Content-Type set with WithRequestHeader.
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// WithCodec registers codec for its content type. The first registered codec
+// is used to encode request bodies when no content type is requested.
+func WithCodec(codec Codec) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.codecs = append(r.codecs, codec)
+	}
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string { return "application/json" }
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type XMLCodec struct{}
+
+func (XMLCodec) ContentType() string { return "application/xml" }
+
+func (XMLCodec) Marshal(v interface{}) ([]byte, error) { return xml.Marshal(v) }
+
+func (XMLCodec) Unmarshal(data []byte, v interface{}) error { return xml.Unmarshal(data, v) }
+
+// ProtobufCodec adapts messages that marshal themselves, without pulling a
+// protobuf library into this package: it only handles values with Marshal
+// and Unmarshal methods, as generated by gogo/protobuf. Messages generated
+// for google.golang.org/protobuf have no such methods; register a Codec
+// calling proto.Marshal and proto.Unmarshal for them.
+type ProtobufCodec struct{}
+
+type protoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+func (ProtobufCodec) ContentType() string { return "application/x-protobuf" }
+
+func (ProtobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(protoMessage)
+	if !ok {
+		return nil, fmt.Errorf("protobuf codec: %T has no Marshal method", v)
+	}
+	return m.Marshal()
+}
+
+func (ProtobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(protoMessage)
+	if !ok {
+		return fmt.Errorf("protobuf codec: %T has no Unmarshal method", v)
+	}
+	return m.Unmarshal(data)
+}
+
+// MsgpackCodec is the msgpack counterpart of ProtobufCodec: it only handles
+// values with MarshalMsg and UnmarshalMsg methods, as generated by
+// tinylib/msgp. Other values need a Codec built on a msgpack library.
+type MsgpackCodec struct{}
+
+type msgpMessage interface {
+	MarshalMsg(b []byte) ([]byte, error)
+	UnmarshalMsg(b []byte) ([]byte, error)
+}
+
+func (MsgpackCodec) ContentType() string { return "application/msgpack" }
+
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(msgpMessage)
+	if !ok {
+		return nil, fmt.Errorf("msgpack codec: %T has no MarshalMsg method", v)
+	}
+	return m.MarshalMsg(nil)
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(msgpMessage)
+	if !ok {
+		return fmt.Errorf("msgpack codec: %T has no UnmarshalMsg method", v)
+	}
+	_, err := m.UnmarshalMsg(data)
+	return err
+}
+
+var builtinCodecs = []Codec{JSONCodec{}, XMLCodec{}}
+
+// codecFor finds the codec for contentType, honoring structured syntax
+// suffixes such as application/problem+json.
+func codecFor(codecs []Codec, contentType string) (Codec, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	all := append(append([]Codec(nil), codecs...), builtinCodecs...)
+	for _, codec := range all {
+		if codec.ContentType() == mediaType {
+			return codec, nil
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+json") || mediaType == "text/json":
+		return JSONCodec{}, nil
+	case strings.HasSuffix(mediaType, "+xml") || mediaType == "text/xml":
+		return XMLCodec{}, nil
+	case mediaType == "application/protobuf" || mediaType == "application/vnd.google.protobuf":
+		return codecFor(codecs, "application/x-protobuf")
+	case strings.HasSuffix(mediaType, "+cbor"):
+		return codecFor(codecs, "application/cbor")
+	case mediaType == "application/x-msgpack" || mediaType == "application/vnd.msgpack":
+		return codecFor(codecs, "application/msgpack")
+	}
+	return nil, fmt.Errorf("no codec registered for %q", mediaType)
+}
+
+func (r *RestHttp) defaultCodec() Codec {
+	if len(r.codecs) > 0 {
+		return r.codecs[0]
+	}
+	return JSONCodec{}
+}
+
+// Decode unmarshals the body into v with the codec matching the response
+// Content-Type.
+func (resp *Response) Decode(v interface{}) error {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return JSONCodec{}.Unmarshal(resp.Body, v)
+	}
+	codec, err := codecFor(resp.codecs, contentType)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(resp.Body, v)
+}
+
+// Encode sets v as the request body, encoded with the client's default
+// codec.
+func (b *RequestBuilder) Encode(v interface{}) *RequestBuilder {
+	return b.encodeWith(b.client.defaultCodec(), v)
+}
+
+// EncodeAs sets v as the request body, encoded with the codec registered for
+// contentType.
+func (b *RequestBuilder) EncodeAs(contentType string, v interface{}) *RequestBuilder {
+	codec, err := codecFor(b.client.codecs, contentType)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	return b.encodeWith(codec, v)
+}
+
+func (b *RequestBuilder) encodeWith(codec Codec, v interface{}) *RequestBuilder {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	return b.Body(data, codec.ContentType())
+}
@@ -0,0 +1,231 @@
+package resthttp
+
+import (
+	"encoding/hex"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCBORMarshal(t *testing.T) {
+	// Expected encodings are from RFC 8949 appendix A.
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{1000, "1903e8"},
+		{uint64(18446744073709551615), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-1000, "3903e7"},
+		{1.1, "fb3ff199999999999a"},
+		{float32(100000), "fa47c35000"},
+		{false, "f4"},
+		{nil, "f6"},
+		{"", "60"},
+		{"ü", "62c3bc"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{[]int{1, 2, 3}, "83010203"},
+		{[]interface{}{1, []int{2, 3}}, "8201820203"},
+		{map[string]string{"b": "B", "a": "A"}, "a26161614161626142"},
+		{map[int]int{3: 4, 1: 2}, "a201020304"},
+	}
+	for _, tt := range tests {
+		got, err := CBORCodec{}.Marshal(tt.value)
+		if err != nil {
+			t.Errorf("Marshal(%#v): %s", tt.value, err)
+			continue
+		}
+		if want := hexBytes(t, tt.want); string(got) != string(want) {
+			t.Errorf("Marshal(%#v) = %x, want %x", tt.value, got, want)
+		}
+	}
+}
+
+func TestCBORUnmarshal(t *testing.T) {
+	tests := []struct {
+		data string
+		want interface{}
+	}{
+		{"1bffffffffffffffff", uint64(18446744073709551615)},
+		{"3903e7", int64(-1000)},
+		{"f93c00", 1.0},
+		{"f97bff", 65504.0},
+		{"f9c400", -4.0},
+		{"f97c00", math.Inf(1)},
+		{"c074323031332d30332d32315432303a30343a30305a", "2013-03-21T20:04:00Z"},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9f018202039f0405ffff", []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{int64(4), int64(5)}}},
+		{"bf61610161629f0203ffff", map[string]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+		{"a201020304", map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)}},
+	}
+	for _, tt := range tests {
+		var got interface{}
+		if err := (CBORCodec{}).Unmarshal(hexBytes(t, tt.data), &got); err != nil {
+			t.Errorf("Unmarshal(%s): %s", tt.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Unmarshal(%s) = %#v, want %#v", tt.data, got, tt.want)
+		}
+	}
+
+	for _, data := range []string{"", "18", "5a00010000", "9f01", "82", "0000", "1c", "a1a0a0a0"} {
+		var got interface{}
+		if err := (CBORCodec{}).Unmarshal(hexBytes(t, data), &got); err == nil {
+			t.Errorf("Unmarshal(%s) = %#v, want an error", data, got)
+		}
+	}
+
+	// Byte string and array keys cannot be stored in an interface-keyed map.
+	for _, data := range []string{"a1410101", "a1820102f5"} {
+		got := map[interface{}]interface{}{}
+		if err := (CBORCodec{}).Unmarshal(hexBytes(t, data), &got); err == nil {
+			t.Errorf("Unmarshal(%s) into map[interface{}]interface{} = %#v, want an error", data, got)
+		}
+	}
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	type inner struct {
+		Tags []string `json:"tags"`
+	}
+	type item struct {
+		inner
+		ID      int64             `cbor:"id"`
+		Name    string            `json:"name"`
+		Skipped string            `json:"-"`
+		Note    string            `json:"note,omitempty"`
+		Price   float64           `json:"price"`
+		Data    []byte            `json:"data"`
+		Labels  map[string]string `json:"labels"`
+		Parent  *item             `json:"parent"`
+		Created time.Time         `json:"created"`
+	}
+	in := item{
+		inner:   inner{Tags: []string{"a", "b"}},
+		ID:      -42,
+		Name:    "widget",
+		Skipped: "not sent",
+		Price:   9.5,
+		Data:    []byte{0, 1, 2},
+		Labels:  map[string]string{"env": "test"},
+		Parent:  &item{ID: 1, Name: "root"},
+		Created: time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC),
+	}
+	data, err := CBORCodec{}.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out item
+	if err := (CBORCodec{}).Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	in.Skipped = ""
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip gave %+v, want %+v", out, in)
+	}
+
+	var generic map[string]interface{}
+	if err := (CBORCodec{}).Unmarshal(data, &generic); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := generic["note"]; ok {
+		t.Error("empty omitempty field was encoded")
+	}
+	if generic["id"] != int64(-42) || generic["name"] != "widget" {
+		t.Errorf("got %v, want the cbor and json tag names", generic)
+	}
+
+	var overflow struct{ ID int8 }
+	if err := (CBORCodec{}).Unmarshal(hexBytes(t, "a162696419ffff"), &overflow); err == nil {
+		t.Errorf("decoded 65535 into an int8 as %d", overflow.ID)
+	}
+}
+
+func TestPostRequestCodec(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []RequestOption
+		want     string
+		wantBody string
+	}{
+		{"form", nil, "application/x-www-form-urlencoded", "a=1"},
+		{"cbor", []RequestOption{WithRequestHeader("Content-Type", "application/cbor")}, "application/cbor", "\xa1\x61a\x611"},
+		{"json", []RequestOption{WithRequestHeader("Content-Type", "application/json")}, "application/json", `{"a":"1"}`},
+		{"explicit serializer", []RequestOption{WithRequestHeader("Content-Type", "application/cbor"), WithParamsSerializer(QueryParams)}, "application/cbor", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contentType string
+			var body []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				contentType = req.Header.Get("Content-Type")
+				body, _ = io.ReadAll(req.Body)
+			}))
+			defer srv.Close()
+
+			r := NewRestHttp(srv.URL, WithCodec(CBORCodec{}))
+			if _, err := r.PostRequest("items", "", map[string][]string{"a": {"1"}}, "", tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if contentType != tt.want {
+				t.Errorf("sent Content-Type %q, want %q", contentType, tt.want)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("sent body %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func hexBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// selfMarshaling implements the methods gogo/protobuf and tinylib/msgp
+// generate.
+type selfMarshaling struct{ data string }
+
+func (m *selfMarshaling) Marshal() ([]byte, error)    { return []byte(m.data), nil }
+func (m *selfMarshaling) Unmarshal(data []byte) error { m.data = string(data); return nil }
+func (m *selfMarshaling) MarshalMsg(b []byte) ([]byte, error) {
+	return append(b, m.data...), nil
+}
+func (m *selfMarshaling) UnmarshalMsg(b []byte) ([]byte, error) {
+	m.data = string(b)
+	return nil, nil
+}
+
+func TestMethodCodecs(t *testing.T) {
+	for _, codec := range []Codec{ProtobufCodec{}, MsgpackCodec{}} {
+		data, err := codec.Marshal(&selfMarshaling{data: "payload"})
+		if err != nil || string(data) != "payload" {
+			t.Errorf("%T.Marshal = %q, %v", codec, data, err)
+		}
+		var out selfMarshaling
+		if err := codec.Unmarshal(data, &out); err != nil || out.data != "payload" {
+			t.Errorf("%T.Unmarshal = %q, %v", codec, out.data, err)
+		}
+		if _, err := codec.Marshal(map[string]string{}); err == nil {
+			t.Errorf("%T marshaled a value without marshaling methods", codec)
+		}
+	}
+
+	codec, err := codecFor([]Codec{ProtobufCodec{}}, "application/vnd.google.protobuf")
+	if err != nil || codec != (ProtobufCodec{}) {
+		t.Errorf("codecFor(application/vnd.google.protobuf) = %v, %v", codec, err)
+	}
+}
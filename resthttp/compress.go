@@ -0,0 +1,225 @@
+package resthttp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ContentDecoder wraps a response body encoded with a particular
+// Content-Encoding. Register decoders for encodings the standard library does
+// not cover, such as zstd or br, with WithContentDecoder.
+type ContentDecoder func(r io.Reader) (io.ReadCloser, error)
+
+// WithRequestGzip gzips request bodies of at least minSize bytes and marks
+// them with Content-Encoding: gzip. Bodies that already carry a
+// Content-Encoding are left alone.
+func WithRequestGzip(minSize int) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.gzipRequests = true
+		r.gzipMinSize = minSize
+	}
+}
+
+// WithResponseDecompression advertises gzip, deflate and every registered
+// decoder in Accept-Encoding and transparently decodes responses.
+//
+// Only gzip and deflate are built in. zstd and brotli are not: the standard
+// library has no decoder for them and this package adds no dependencies. They
+// are neither advertised nor decoded until registered, for example with
+// github.com/klauspost/compress/zstd and github.com/andybalholm/brotli:
+//
+//	resthttp.WithContentDecoder("zstd", func(r io.Reader) (io.ReadCloser, error) {
+//		d, err := zstd.NewReader(r)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return d.IOReadCloser(), nil
+//	}),
+//	resthttp.WithContentDecoder("br", func(r io.Reader) (io.ReadCloser, error) {
+//		return io.NopCloser(brotli.NewReader(r)), nil
+//	}),
+func WithResponseDecompression() func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.decompress = true
+	}
+}
+
+// WithContentDecoder registers a decoder for encoding and enables response
+// decompression.
+func WithContentDecoder(encoding string, decoder ContentDecoder) func(*RestHttp) {
+	return func(r *RestHttp) {
+		if r.decoders == nil {
+			r.decoders = make(map[string]ContentDecoder)
+		}
+		r.decoders[strings.ToLower(encoding)] = decoder
+		r.decompress = true
+	}
+}
+
+func gzipDecoder(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// deflateDecoder accepts both zlib wrapped (as the RFC says) and raw deflate
+// streams, since servers send either.
+func deflateDecoder(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 && header[0]&0x0f == 8 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+func (r *RestHttp) contentDecoder(encoding string) ContentDecoder {
+	if decoder, ok := r.decoders[encoding]; ok {
+		return decoder
+	}
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzipDecoder
+	case "deflate":
+		return deflateDecoder
+	}
+	return nil
+}
+
+func (r *RestHttp) acceptEncoding() string {
+	encodings := []string{"gzip", "deflate"}
+	var extra []string
+	for encoding := range r.decoders {
+		if encoding != "gzip" && encoding != "deflate" {
+			extra = append(extra, encoding)
+		}
+	}
+	sort.Strings(extra)
+	return strings.Join(append(encodings, extra...), ", ")
+}
+
+func (r *RestHttp) compressRequest(req *http.Request) error {
+	if !r.gzipRequests || req.Body == nil || req.GetBody == nil || req.ContentLength <= 0 {
+		return nil
+	}
+	if req.ContentLength < int64(r.gzipMinSize) || req.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	req.Body.Close()
+	data := buf.Bytes()
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Encoding", "gzip")
+	return nil
+}
+
+// decodeResponse replaces resp.Body with a decoding reader for every
+// Content-Encoding applied by the server.
+func (r *RestHttp) decodeResponse(resp *http.Response) error {
+	encodings := splitHeaderList(resp.Header.Values("Content-Encoding"))
+	if len(encodings) == 0 {
+		return nil
+	}
+
+	body := resp.Body
+	closers := []io.Closer{resp.Body}
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(encodings[i])
+		if encoding == "identity" {
+			continue
+		}
+		decoder := r.contentDecoder(encoding)
+		if decoder == nil {
+			return fmt.Errorf("unsupported content encoding: %s", encoding)
+		}
+		decoded, err := decoder(body)
+		if err != nil {
+			return fmt.Errorf("could not decode %s response: %s", encoding, err)
+		}
+		body = decoded
+		closers = append(closers, decoded)
+	}
+
+	resp.Body = &multiCloser{Reader: body, closers: closers}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	var first error
+	for i := len(m.closers) - 1; i >= 0; i-- {
+		if err := m.closers[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+var compressedTypes = map[string]bool{
+	"application/gzip":            true,
+	"application/x-gzip":          true,
+	"application/zip":             true,
+	"application/zstd":            true,
+	"application/x-bzip2":         true,
+	"application/x-xz":            true,
+	"application/x-7z-compressed": true,
+	"application/x-compress":      true,
+}
+
+var compressedExtensions = map[string]bool{
+	".gz":  true,
+	".tgz": true,
+	".zip": true,
+	".zst": true,
+	".br":  true,
+	".bz2": true,
+	".xz":  true,
+	".7z":  true,
+	".z":   true,
+}
+
+// isCompressedArtifact reports whether a download is itself a compressed
+// file, in which case a Content-Encoding header most likely describes the
+// stored object and decoding it would corrupt the artifact.
+func isCompressedArtifact(resp *http.Response, savePath string) bool {
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && compressedTypes[mediaType] {
+		return true
+	}
+	return compressedExtensions[strings.ToLower(filepath.Ext(savePath))]
+}
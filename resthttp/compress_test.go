@@ -0,0 +1,61 @@
+package resthttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseDecompression(t *testing.T) {
+	var accepted string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		accepted = req.Header.Get("Accept-Encoding")
+		switch {
+		case strings.Contains(accepted, "zstd"):
+			w.Header().Set("Content-Encoding", "zstd")
+			w.Write([]byte("ZSTD:payload"))
+		default:
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte("payload"))
+			gz.Close()
+		}
+	}))
+	defer srv.Close()
+
+	// A stand-in for a zstd library, stripping a marker instead.
+	fakeZstd := func(r io.Reader) (io.ReadCloser, error) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(bytes.TrimPrefix(data, []byte("ZSTD:")))), nil
+	}
+
+	tests := []struct {
+		name       string
+		option     func(*RestHttp)
+		wantAccept string
+	}{
+		{"built in", WithResponseDecompression(), "gzip, deflate"},
+		{"registered zstd", WithContentDecoder("zstd", fakeZstd), "gzip, deflate, zstd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := NewRestHttp(srv.URL, tt.option).GetRequest("items", "1", nil, "", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if accepted != tt.wantAccept {
+				t.Errorf("sent Accept-Encoding %q, want %q", accepted, tt.wantAccept)
+			}
+			if string(body) != "payload" {
+				t.Errorf("got body %q, want payload", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package resthttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// These tests exercise state shared between requests and are meant to be
+// run with -race.
+
+const concurrentRequests = 32
+
+func TestConcurrentRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*RestHttp)
+		handler http.HandlerFunc
+		// setup runs before the concurrent requests.
+		setup func(r *RestHttp) error
+		// request is sent by every goroutine with its index.
+		request func(r *RestHttp, i int) error
+		check   func(t *testing.T, r *RestHttp, served int64)
+	}{
+		{
+			name:    "cache revalidation",
+			options: []func(*RestHttp){WithCache(NewMemoryCacheWithLimits(4, 0))},
+			handler: func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=0")
+				w.Header().Set("ETag", `"v1"`)
+				if req.Header.Get("If-None-Match") == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Write([]byte("cached"))
+			},
+			setup: func(r *RestHttp) error {
+				for i := 0; i < 6; i++ {
+					if err := expectBody(r, "items", strconv.Itoa(i), "cached"); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			request: func(r *RestHttp, i int) error {
+				return expectBody(r, "items", strconv.Itoa(i%6), "cached")
+			},
+			check: func(t *testing.T, r *RestHttp, served int64) {
+				if stats := r.Stats(); stats.CacheRevalidations == 0 {
+					t.Errorf("no revalidations in %+v", stats)
+				}
+			},
+		},
+		{
+			name:    "coalescing",
+			options: []func(*RestHttp){WithRequestCoalescing()},
+			handler: func(w http.ResponseWriter, req *http.Request) {
+				time.Sleep(20 * time.Millisecond)
+				w.Write([]byte("shared"))
+			},
+			request: func(r *RestHttp, i int) error {
+				return expectBody(r, "items", "1", "shared")
+			},
+			check: func(t *testing.T, r *RestHttp, served int64) {
+				if served >= concurrentRequests {
+					t.Errorf("server saw %d requests, want fewer than %d", served, concurrentRequests)
+				}
+				if stats := r.Stats(); stats.Coalesced == 0 {
+					t.Errorf("no coalesced requests in %+v", stats)
+				}
+			},
+		},
+		{
+			name:    "tenant pools",
+			options: []func(*RestHttp){WithTenantIsolation(nil), WithMaxTenants(4)},
+			handler: okHandler,
+			request: func(r *RestHttp, i int) error {
+				_, err := r.GetRequest("items", "1", nil, "", false, WithTenant(strconv.Itoa(i%8)))
+				return err
+			},
+			check: func(t *testing.T, r *RestHttp, served int64) {
+				r.tenants.mu.Lock()
+				defer r.tenants.mu.Unlock()
+				if n := len(r.tenants.pools); n > 4 || n != r.tenants.lru.Len() {
+					t.Errorf("%d pools and %d LRU entries, want at most 4", n, r.tenants.lru.Len())
+				}
+			},
+		},
+		{
+			name:    "stats",
+			handler: okHandler,
+			request: func(r *RestHttp, i int) error {
+				_, err := r.GetRequest("items", strconv.Itoa(i), nil, "", false)
+				return err
+			},
+			check: func(t *testing.T, r *RestHttp, served int64) {
+				stats := r.Stats()
+				if stats.Requests != concurrentRequests || stats.InFlight != 0 {
+					t.Errorf("got %+v, want %d requests and none in flight", stats, concurrentRequests)
+				}
+			},
+		},
+		{
+			name:    "history",
+			options: []func(*RestHttp){WithHistory(8)},
+			handler: okHandler,
+			request: func(r *RestHttp, i int) error {
+				_, err := r.GetRequest("items", strconv.Itoa(i), nil, "", false)
+				return err
+			},
+			check: func(t *testing.T, r *RestHttp, served int64) {
+				if n := len(r.History()); n != 8 {
+					t.Errorf("history holds %d entries, want 8", n)
+				}
+			},
+		},
+		{
+			name:    "settings",
+			handler: okHandler,
+			request: func(r *RestHttp, i int) error {
+				r.SetBaseHeader("X-Request", strconv.Itoa(i))
+				r.SetCredentials("user", strconv.Itoa(i))
+				_, err := r.WithHeader("X-Scope", strconv.Itoa(i)).GetRequest("items", "1", nil, "", false)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var served atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				served.Add(1)
+				tt.handler(w, req)
+			}))
+			defer srv.Close()
+
+			r := NewRestHttp(srv.URL, tt.options...)
+			if tt.setup != nil {
+				if err := tt.setup(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var wg sync.WaitGroup
+			errs := make(chan error, concurrentRequests)
+			for i := 0; i < concurrentRequests; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					if err := tt.request(r, i); err != nil {
+						errs <- err
+					}
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Error(err)
+			}
+			if tt.check != nil {
+				tt.check(t, r, served.Load())
+			}
+		})
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int64
+		sizes      []int
+		want       []bool
+	}{
+		{name: "entry limit", maxEntries: 2, sizes: []int{1, 1, 1}, want: []bool{false, true, true}},
+		{name: "byte limit", maxBytes: 10, sizes: []int{4, 4, 4}, want: []bool{false, true, true}},
+		{name: "oversized entry", maxBytes: 10, sizes: []int{4, 11}, want: []bool{true, false}},
+		{name: "unlimited", sizes: []int{100, 100, 100}, want: []bool{true, true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewMemoryCacheWithLimits(tt.maxEntries, tt.maxBytes)
+			for i, size := range tt.sizes {
+				c.Set(strconv.Itoa(i), &CacheEntry{Body: make([]byte, size)})
+			}
+			for i, want := range tt.want {
+				if _, ok := c.Get(strconv.Itoa(i)); ok != want {
+					t.Errorf("entry %d cached = %v, want %v", i, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func okHandler(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+func expectBody(r *RestHttp, container string, resource string, want string) error {
+	body, err := r.GetRequest(container, resource, nil, "", false)
+	if err != nil {
+		return err
+	}
+	if string(body) != want {
+		return fmt.Errorf("got body %q, want %q", body, want)
+	}
+	return nil
+}
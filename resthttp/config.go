@@ -0,0 +1,368 @@
+package resthttp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientConfig describes a client in a form that can be read from a
+// configuration file or the environment, so that endpoints can be changed
+// without recompiling:
+//
+//	{
+//		"base_url": "https://api.example.com",
+//		"headers": {"X-Team": "billing"},
+//		"auth": {"token": "..."},
+//		"tls": {"ca_file": "/etc/ssl/internal-ca.pem"},
+//		"proxy": "http://proxy.internal:3128",
+//		"timeout": "30s",
+//		"retry": {"max_attempts": 3, "initial_backoff": "200ms"}
+//	}
+type ClientConfig struct {
+	BaseURL  string            `json:"base_url" yaml:"base_url"`
+	Headers  map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Auth     AuthConfig        `json:"auth" yaml:"auth"`
+	TLS      TLSConfig         `json:"tls" yaml:"tls"`
+	Proxy    string            `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	Timeout  Duration          `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Retry    *RetryConfig      `json:"retry,omitempty" yaml:"retry,omitempty"`
+	LogLevel string            `json:"log_level,omitempty" yaml:"log_level,omitempty"`
+}
+
+// AuthConfig selects basic auth when User is set, or a token otherwise.
+type AuthConfig struct {
+	User        string `json:"user,omitempty" yaml:"user,omitempty"`
+	Password    string `json:"password,omitempty" yaml:"password,omitempty"`
+	Token       string `json:"token,omitempty" yaml:"token,omitempty"`
+	TokenScheme string `json:"token_scheme,omitempty" yaml:"token_scheme,omitempty"`
+}
+
+type TLSConfig struct {
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+	CAFile             string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile            string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	ServerName         string `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+}
+
+type RetryConfig struct {
+	MaxAttempts    int      `json:"max_attempts" yaml:"max_attempts"`
+	InitialBackoff Duration `json:"initial_backoff,omitempty" yaml:"initial_backoff,omitempty"`
+	MaxBackoff     Duration `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`
+	RetryOn        []int    `json:"retry_on,omitempty" yaml:"retry_on,omitempty"`
+}
+
+// Duration is a time.Duration written as a string like "1m30s" in
+// configuration files.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ConfigDecoder decodes a configuration file into v.
+type ConfigDecoder func(data []byte, v interface{}) error
+
+var (
+	configDecodersMu sync.RWMutex
+	configDecoders   = map[string]ConfigDecoder{
+		".json": json.Unmarshal,
+		".yaml": decodeYAML,
+		".yml":  decodeYAML,
+	}
+)
+
+// RegisterConfigDecoder makes LoadConfig accept files with the given
+// extension. JSON and YAML files are understood out of the box; the built-in
+// YAML decoder covers what configuration files need but no anchors, aliases
+// or tags. Registering a YAML library lifts that limit:
+//
+//	resthttp.RegisterConfigDecoder(".yaml", yaml.Unmarshal)
+//	resthttp.RegisterConfigDecoder(".yml", yaml.Unmarshal)
+func RegisterConfigDecoder(ext string, decode ConfigDecoder) {
+	configDecodersMu.Lock()
+	defer configDecodersMu.Unlock()
+	configDecoders[strings.ToLower(ext)] = decode
+}
+
+// LoadConfig reads a configuration file, choosing the decoder by extension:
+// .json, .yaml and .yml unless more are registered.
+func LoadConfig(path string) (*ClientConfig, error) {
+	configDecodersMu.RLock()
+	decode, ok := configDecoders[strings.ToLower(filepath.Ext(path))]
+	configDecodersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no decoder registered for configuration file %s", path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &ClientConfig{}
+	if err := decode(data, cfg); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", path, err)
+	}
+	return cfg, nil
+}
+
+// DefaultEnvPrefix prefixes the environment variables read by ConfigFromEnv.
+const DefaultEnvPrefix = "RESTHTTP"
+
+// ConfigFromEnv reads a configuration from environment variables named
+// after prefix, DefaultEnvPrefix when empty:
+//
+//	RESTHTTP_CONFIG       configuration file read first, overridden by the rest
+//	RESTHTTP_BASE_URL
+//	RESTHTTP_HEADERS      comma separated Name=value pairs
+//	RESTHTTP_USER, RESTHTTP_PASSWORD, RESTHTTP_TOKEN, RESTHTTP_TOKEN_SCHEME
+//	RESTHTTP_INSECURE_SKIP_VERIFY, RESTHTTP_CA_FILE, RESTHTTP_CERT_FILE,
+//	RESTHTTP_KEY_FILE, RESTHTTP_SERVER_NAME
+//	RESTHTTP_PROXY
+//	RESTHTTP_TIMEOUT      a duration like 30s
+//	RESTHTTP_RETRY_MAX_ATTEMPTS, RESTHTTP_RETRY_INITIAL_BACKOFF,
+//	RESTHTTP_RETRY_MAX_BACKOFF
+//	RESTHTTP_LOG_LEVEL    off, error, info or debug
+func ConfigFromEnv(prefix string) (*ClientConfig, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	env := func(name string) (string, bool) {
+		return os.LookupEnv(prefix + "_" + name)
+	}
+
+	cfg := &ClientConfig{}
+	if path, ok := env("CONFIG"); ok && path != "" {
+		loaded, err := LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg = loaded
+	}
+
+	strs := []struct {
+		name   string
+		target *string
+	}{
+		{"BASE_URL", &cfg.BaseURL},
+		{"USER", &cfg.Auth.User},
+		{"PASSWORD", &cfg.Auth.Password},
+		{"TOKEN", &cfg.Auth.Token},
+		{"TOKEN_SCHEME", &cfg.Auth.TokenScheme},
+		{"CA_FILE", &cfg.TLS.CAFile},
+		{"CERT_FILE", &cfg.TLS.CertFile},
+		{"KEY_FILE", &cfg.TLS.KeyFile},
+		{"SERVER_NAME", &cfg.TLS.ServerName},
+		{"PROXY", &cfg.Proxy},
+		{"LOG_LEVEL", &cfg.LogLevel},
+	}
+	for _, s := range strs {
+		if value, ok := env(s.name); ok {
+			*s.target = value
+		}
+	}
+
+	if value, ok := env("HEADERS"); ok {
+		cfg.Headers = make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			name, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid header %q in %s_HEADERS, expected Name=value", pair, prefix)
+			}
+			cfg.Headers[strings.TrimSpace(name)] = strings.TrimSpace(val)
+		}
+	}
+
+	if value, ok := env("INSECURE_SKIP_VERIFY"); ok {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s_INSECURE_SKIP_VERIFY: %s", prefix, err)
+		}
+		cfg.TLS.InsecureSkipVerify = insecure
+	}
+
+	durations := []struct {
+		name   string
+		target func() *Duration
+	}{
+		{"TIMEOUT", func() *Duration { return &cfg.Timeout }},
+		{"RETRY_INITIAL_BACKOFF", func() *Duration { return &cfg.retryConfig().InitialBackoff }},
+		{"RETRY_MAX_BACKOFF", func() *Duration { return &cfg.retryConfig().MaxBackoff }},
+	}
+	for _, d := range durations {
+		if value, ok := env(d.name); ok {
+			if err := d.target().UnmarshalText([]byte(value)); err != nil {
+				return nil, fmt.Errorf("invalid %s_%s: %s", prefix, d.name, err)
+			}
+		}
+	}
+	if value, ok := env("RETRY_MAX_ATTEMPTS"); ok {
+		attempts, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s_RETRY_MAX_ATTEMPTS: %s", prefix, err)
+		}
+		cfg.retryConfig().MaxAttempts = attempts
+	}
+	return cfg, nil
+}
+
+func (cfg *ClientConfig) retryConfig() *RetryConfig {
+	if cfg.Retry == nil {
+		cfg.Retry = &RetryConfig{}
+	}
+	return cfg.Retry
+}
+
+// Options converts the configuration into client options. Files named in
+// the TLS section are read here.
+func (cfg *ClientConfig) Options() ([]func(*RestHttp), error) {
+	var options []func(*RestHttp)
+
+	if len(cfg.Headers) > 0 {
+		headers := cfg.Headers
+		options = append(options, func(r *RestHttp) {
+			if r.BaseHeaders == nil {
+				r.BaseHeaders = make(http.Header)
+			}
+			for name, value := range headers {
+				r.BaseHeaders.Set(name, value)
+			}
+		})
+	}
+
+	switch {
+	case cfg.Auth.User != "":
+		options = append(options, WithUser(cfg.Auth.User), WithPassword(cfg.Auth.Password))
+	case cfg.Auth.Token != "":
+		options = append(options, WithAuthProvider(TokenAuth{Token: cfg.Auth.Token, Scheme: cfg.Auth.TokenScheme}))
+	}
+
+	tlsConfig, err := cfg.TLS.build()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		options = append(options, WithTLSConfig(tlsConfig))
+	}
+	if cfg.TLS.InsecureSkipVerify {
+		options = append(options, func(r *RestHttp) { r.VerifySSL = false })
+	}
+
+	if cfg.Proxy != "" {
+		options = append(options, WithProxy(cfg.Proxy))
+	}
+	if cfg.Timeout > 0 {
+		timeout := time.Duration(cfg.Timeout)
+		options = append(options, func(r *RestHttp) { r.Timeout = timeout })
+	}
+	if cfg.Retry != nil {
+		options = append(options, WithRetryPolicy(&RetryPolicy{
+			MaxAttempts:    cfg.Retry.MaxAttempts,
+			InitialBackoff: time.Duration(cfg.Retry.InitialBackoff),
+			MaxBackoff:     time.Duration(cfg.Retry.MaxBackoff),
+			RetryOn:        cfg.Retry.RetryOn,
+		}))
+	}
+	if cfg.LogLevel != "" {
+		level, err := parseLogLevel(cfg.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, WithLogLevel(level))
+	}
+	return options, nil
+}
+
+func (t TLSConfig) build() (*tls.Config, error) {
+	if t.CAFile == "" && t.CertFile == "" && t.KeyFile == "" && t.ServerName == "" {
+		return nil, nil
+	}
+	config := &tls.Config{ServerName: t.ServerName}
+	if t.CAFile != "" {
+		pem, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA file: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %s", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func parseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(level) {
+	case "off", "none":
+		return LogOff, nil
+	case "error":
+		return LogError, nil
+	case "info":
+		return LogInfo, nil
+	case "debug":
+		return LogDebug, nil
+	}
+	return LogOff, fmt.Errorf("unknown log level %q", level)
+}
+
+// NewRestHttpFromConfig creates a client from the configuration file at
+// path. options are applied after the configuration.
+func NewRestHttpFromConfig(path string, options ...func(*RestHttp)) (*RestHttp, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.NewClient(options...)
+}
+
+// NewRestHttpFromEnv creates a client from the environment variables
+// described at ConfigFromEnv.
+func NewRestHttpFromEnv(prefix string, options ...func(*RestHttp)) (*RestHttp, error) {
+	cfg, err := ConfigFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.NewClient(options...)
+}
+
+// NewClient creates a client from the configuration.
+func (cfg *ClientConfig) NewClient(options ...func(*RestHttp)) (*RestHttp, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("configuration has no base_url")
+	}
+	configured, err := cfg.Options()
+	if err != nil {
+		return nil, err
+	}
+	return NewRestHttp(strings.TrimRight(cfg.BaseURL, "/"), append(configured, options...)...), nil
+}
@@ -0,0 +1,118 @@
+package resthttp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigYAML(t *testing.T) {
+	const jsonConfig = `{
+	"base_url": "https://api.example.com/v1#ignored",
+	"headers": {"X-Team": "billing", "X-Id": "007"},
+	"auth": {"user": "svc", "password": "p#ss: word"},
+	"tls": {"insecure_skip_verify": true, "ca_file": "/etc/ssl/ca.pem"},
+	"proxy": "http://proxy.internal:3128",
+	"timeout": "30s",
+	"retry": {"max_attempts": 3, "initial_backoff": "200ms", "retry_on": [502, 503]},
+	"log_level": "debug"
+}`
+	const yamlConfig = `---
+# Billing API
+base_url: "https://api.example.com/v1#ignored"
+headers:
+  X-Team: billing   # owning team
+  X-Id: '007'
+auth:
+  user: svc
+  password: "p#ss: word"
+tls: {insecure_skip_verify: true, ca_file: /etc/ssl/ca.pem}
+proxy: http://proxy.internal:3128
+timeout: 30s
+retry:
+  max_attempts: 3
+  initial_backoff: 200ms
+  retry_on:
+  - 502
+  - 503
+log_level: debug
+`
+	dir := t.TempDir()
+	load := func(name, content string) *ClientConfig {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+
+	want := load("client.json", jsonConfig)
+	for _, name := range []string{"client.yaml", "client.yml"} {
+		if got := load(name, yamlConfig); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s decoded to %+v, want %+v like the JSON file", name, got, want)
+		}
+	}
+	if want.Timeout != Duration(30*time.Second) || want.Headers["X-Id"] != "007" {
+		t.Errorf("unexpected JSON config %+v", want)
+	}
+}
+
+func TestDecodeYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want interface{}
+	}{
+		{"scalars", "a: 1\nb: -2.5\nc: true\nd: ~\ne: text with spaces\nf: 'it''s'\ng: \"tab\\t\"\n", map[string]interface{}{
+			"a": int64(1), "b": -2.5, "c": true, "d": nil, "e": "text with spaces", "f": "it's", "g": "tab\t",
+		}},
+		{"nested sequences", "- a\n- - b\n  - c\n-\n  - d\n", []interface{}{"a", []interface{}{"b", "c"}, []interface{}{"d"}}},
+		{"mappings in a sequence", "items:\n  - name: a\n    size: 1\n  - name: b\n", map[string]interface{}{
+			"items": []interface{}{map[string]interface{}{"name": "a", "size": int64(1)}, map[string]interface{}{"name": "b"}},
+		}},
+		{"flow", "a: [1, [2, 3], {b: c, d: 'e, f'}]\nempty: []\n", map[string]interface{}{
+			"a":     []interface{}{int64(1), []interface{}{int64(2), int64(3)}, map[string]interface{}{"b": "c", "d": "e, f"}},
+			"empty": []interface{}{},
+		}},
+		{"block scalars", "literal: |\n  line one\n    indented\n\n  # kept\nfolded: >-\n  one\n  two\n\n  three\nnext: x\n", map[string]interface{}{
+			"literal": "line one\n  indented\n\n# kept\n", "folded": "one two\nthree", "next": "x",
+		}},
+		{"empty document", "# nothing here\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got interface{}
+			if err := decodeYAML([]byte(tt.src), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	invalid := []string{
+		"a: 1\n  b: 2\n",
+		"a: 1\na: 2\n",
+		"a: &anchor 1\n",
+		"a: [1, 2\n",
+		"a:\n\t- b\n",
+		"a: 1\n---\nb: 2\n",
+		"a: 1\n- b\n",
+		"timeout: soon\n",
+		"retry: [1]\n",
+	}
+	for _, src := range invalid {
+		var cfg ClientConfig
+		if err := decodeYAML([]byte(src), &cfg); err == nil {
+			t.Errorf("decoding %q succeeded with %+v", src, cfg)
+		}
+	}
+}
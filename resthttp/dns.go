@@ -0,0 +1,159 @@
+package resthttp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// WithResolver looks up host names with resolver instead of the system
+// resolver, for example one built by NewDNSResolver.
+func WithResolver(resolver *net.Resolver) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.dnsConfig().resolver = resolver
+	}
+}
+
+// WithDNSCache keeps resolved addresses for ttl, sparing a lookup per new
+// connection.
+func WithDNSCache(ttl time.Duration) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.dnsConfig().ttl = ttl
+	}
+}
+
+// WithHostOverride connects to ip whenever host is addressed, like an
+// /etc/hosts entry. The request keeps its Host header and the certificate is
+// still verified against host, which allows testing a staging server under
+// the production name.
+func WithHostOverride(host string, ip string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		dns := r.dnsConfig()
+		if dns.overrides == nil {
+			dns.overrides = make(map[string]string)
+		}
+		dns.overrides[host] = ip
+	}
+}
+
+// NewDNSResolver returns a resolver that sends its queries to server, a
+// host:port such as "10.0.0.2:53" or a host using port 53.
+func NewDNSResolver(server string) *net.Resolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network string, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+type dnsResolver struct {
+	resolver  *net.Resolver
+	ttl       time.Duration
+	overrides map[string]string
+
+	mu    sync.Mutex
+	cache map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// dnsConfig replaces the client's resolver by a copy to be configured, so
+// that clones never change the resolver of the client they came from.
+func (r *RestHttp) dnsConfig() *dnsResolver {
+	dns := &dnsResolver{}
+	if r.dns != nil {
+		dns.resolver = r.dns.resolver
+		dns.ttl = r.dns.ttl
+		if r.dns.overrides != nil {
+			dns.overrides = make(map[string]string, len(r.dns.overrides))
+			for host, ip := range r.dns.overrides {
+				dns.overrides[host] = ip
+			}
+		}
+	}
+	r.dns = dns
+	return dns
+}
+
+// dialer is the dialer of http.DefaultTransport.
+var dialer = &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+// dial connects to addr, resolving its host through the overrides, the cache
+// and the configured resolver, and trying each address in turn.
+func (d *dnsResolver) dial(ctx context.Context, network string, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+func (d *dnsResolver) lookup(ctx context.Context, host string) ([]string, error) {
+	if ip, ok := d.overrides[host]; ok {
+		return []string{ip}, nil
+	}
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	if d.ttl > 0 {
+		d.mu.Lock()
+		entry, ok := d.cache[host]
+		d.mu.Unlock()
+		if ok && time.Now().Before(entry.expires) {
+			return entry.addrs, nil
+		}
+	}
+
+	resolver := d.resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ips, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = ip.String()
+	}
+
+	if d.ttl > 0 {
+		d.mu.Lock()
+		if d.cache == nil {
+			d.cache = make(map[string]dnsEntry)
+		}
+		d.cache[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(d.ttl)}
+		d.mu.Unlock()
+	}
+	return addrs, nil
+}
@@ -0,0 +1,192 @@
+package resthttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DumpMode selects what is written to a debug writer.
+type DumpMode int
+
+const (
+	// DumpCurl writes each outgoing request as a curl command.
+	DumpCurl DumpMode = 1 << iota
+	// DumpWire writes the full request and response as sent and received.
+	DumpWire
+	// DumpSecrets keeps credential headers and query parameters in the
+	// output; they are redacted like in log output otherwise.
+	DumpSecrets
+)
+
+type debugDump struct {
+	mu   *sync.Mutex
+	w    io.Writer
+	mode DumpMode
+}
+
+// WithDebugWriter writes every exchange to w according to mode.
+func WithDebugWriter(w io.Writer, mode DumpMode) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.dump = &debugDump{mu: &sync.Mutex{}, w: w, mode: mode}
+	}
+}
+
+// WithDump writes this request, and its response, to w according to mode,
+// overriding the client's debug writer.
+func WithDump(w io.Writer, mode DumpMode) RequestOption {
+	return func(ro *requestOptions) {
+		ro.dump = &debugDump{mu: &sync.Mutex{}, w: w, mode: mode}
+	}
+}
+
+// CurlCommand renders req as an equivalent curl command line. The request
+// body is preserved.
+func CurlCommand(req *http.Request) (string, error) {
+	body, err := peekBody(req)
+	if err != nil {
+		return "", err
+	}
+	return curlCommand(req, req.Header, body), nil
+}
+
+func curlCommand(req *http.Request, header http.Header, body []byte) string {
+	var cmd strings.Builder
+	cmd.WriteString("curl -X ")
+	cmd.WriteString(req.Method)
+
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			cmd.WriteString(" -H ")
+			cmd.WriteString(shellQuote(key + ": " + value))
+		}
+	}
+	if len(body) > 0 {
+		cmd.WriteString(" --data-binary ")
+		cmd.WriteString(shellQuote(string(body)))
+	}
+	cmd.WriteString(" ")
+	cmd.WriteString(shellQuote(req.URL.String()))
+	return cmd.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// peekBody returns the request body and leaves req with an unread copy.
+func peekBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(rc)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func (r *RestHttp) dumpFor(ro *requestOptions) *debugDump {
+	if ro.dump != nil {
+		return ro.dump
+	}
+	return r.dump
+}
+
+func (r *RestHttp) dumpHeader(d *debugDump, header http.Header) http.Header {
+	if d.mode&DumpSecrets != 0 {
+		return header
+	}
+	return r.redactHeader(header)
+}
+
+// dumpURL masks the credential query parameters of u unless secrets are
+// dumped.
+func (r *RestHttp) dumpURL(d *debugDump, u *url.URL) *url.URL {
+	if d.mode&DumpSecrets != 0 {
+		return u
+	}
+	masked, err := url.Parse(r.redactedURL(u))
+	if err != nil {
+		return u
+	}
+	return masked
+}
+
+func (r *RestHttp) dumpRequest(req *http.Request, ro *requestOptions) error {
+	d := r.dumpFor(ro)
+	if d == nil || d.mode&(DumpCurl|DumpWire) == 0 {
+		return nil
+	}
+	body, err := peekBody(req)
+	if err != nil {
+		return fmt.Errorf("could not dump request: %s", err)
+	}
+	clone := req.Clone(req.Context())
+	clone.Header = r.dumpHeader(d, req.Header)
+	clone.URL = r.dumpURL(d, req.URL)
+
+	var out bytes.Buffer
+	if d.mode&DumpCurl != 0 {
+		out.WriteString(curlCommand(clone, clone.Header, body))
+		out.WriteString("\n")
+	}
+	if d.mode&DumpWire != 0 {
+		clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+		wire, err := httputil.DumpRequestOut(clone, true)
+		if err != nil {
+			return fmt.Errorf("could not dump request: %s", err)
+		}
+		out.Write(wire)
+		out.WriteString("\n")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err = d.w.Write(out.Bytes())
+	return err
+}
+
+// dumpResponse writes resp in wire format. Streamed and downloaded bodies
+// are left out so that reading them is neither delayed nor buffered in
+// memory.
+func (r *RestHttp) dumpResponse(resp *http.Response, ro *requestOptions) error {
+	d := r.dumpFor(ro)
+	if d == nil || d.mode&DumpWire == 0 {
+		return nil
+	}
+	copied := *resp
+	copied.Header = r.dumpHeader(d, resp.Header)
+	wire, err := httputil.DumpResponse(&copied, !ro.stream && !ro.keepEncoded)
+	if err != nil {
+		return fmt.Errorf("could not dump response: %s", err)
+	}
+	resp.Body = copied.Body
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err = fmt.Fprintf(d.w, "%s\n\n", wire)
+	return err
+}
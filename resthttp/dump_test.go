@@ -0,0 +1,84 @@
+package resthttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDumpResponseBody(t *testing.T) {
+	const payload = "downloaded payload"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		do       func(r *RestHttp) error
+		wantBody bool
+	}{
+		{"get", func(r *RestHttp) error {
+			_, err := r.GetRequest("files", "a", nil, "", false)
+			return err
+		}, true},
+		{"download file", func(r *RestHttp) error {
+			return r.DownloadFile("files", "a", filepath.Join(t.TempDir(), "a"), "", nil)
+		}, false},
+		{"download to", func(r *RestHttp) error {
+			_, err := r.DownloadTo(context.Background(), "files", "a", io.Discard)
+			return err
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dump bytes.Buffer
+			r := NewRestHttp(srv.URL, WithDebugWriter(&dump, DumpWire))
+			if err := tt.do(r); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(dump.String(), "HTTP/1.1 200 OK") {
+				t.Fatalf("dump has no response:\n%s", dump.String())
+			}
+			if got := strings.Contains(dump.String(), payload); got != tt.wantBody {
+				t.Errorf("dump contains the body: %v, want %v", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestDumpRequestRedactsQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		mode   DumpMode
+		secret bool
+	}{
+		{"curl", DumpCurl, false},
+		{"wire", DumpWire, false},
+		{"secrets", DumpCurl | DumpWire | DumpSecrets, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dump bytes.Buffer
+			r := NewRestHttp(srv.URL, WithDebugWriter(&dump, tt.mode), WithAPIKey("api_key", "s3cret", APIKeyInQuery))
+			if _, err := r.GetRequest("items", "1", url.Values{"page": {"2"}}, "", false); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(dump.String(), "page=2") {
+				t.Fatalf("dump has no request URL:\n%s", dump.String())
+			}
+			if got := strings.Contains(dump.String(), "s3cret"); got != tt.secret {
+				t.Errorf("dump contains the API key: %v, want %v\n%s", got, tt.secret, dump.String())
+			}
+		})
+	}
+}
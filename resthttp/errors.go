@@ -0,0 +1,194 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxErrorBody bounds how much of an error response is read to build the
+// error.
+const maxErrorBody = 64 << 10
+
+// ErrorRegistry maps error responses to application errors, so callers can
+// test for domain errors such as ErrQuotaExceeded with errors.Is or errors.As
+// instead of parsing RestHttpError text. A registry may be shared between
+// clients.
+type ErrorRegistry struct {
+	mu      sync.RWMutex
+	entries map[errorKey]func(*RestHttpError) error
+}
+
+type errorKey struct {
+	status int
+	code   string
+}
+
+func NewErrorRegistry() *ErrorRegistry {
+	return &ErrorRegistry{entries: make(map[errorKey]func(*RestHttpError) error)}
+}
+
+// Register maps responses with the given status and error code to target.
+// A status of 0 matches any status and an empty code matches any code; the
+// most specific registration wins.
+func (reg *ErrorRegistry) Register(status int, code string, target error) {
+	reg.RegisterFunc(status, code, func(*RestHttpError) error { return target })
+}
+
+// RegisterFunc is like Register but builds the error from the response,
+// for error types that carry details.
+func (reg *ErrorRegistry) RegisterFunc(status int, code string, build func(*RestHttpError) error) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.entries[errorKey{status, code}] = build
+}
+
+func (reg *ErrorRegistry) lookup(status int, code string) func(*RestHttpError) error {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	for _, key := range []errorKey{{status, code}, {0, code}, {status, ""}} {
+		if key.code == "" && key.status == 0 {
+			continue
+		}
+		if build, ok := reg.entries[key]; ok {
+			return build
+		}
+	}
+	return nil
+}
+
+func WithErrorRegistry(registry *ErrorRegistry) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.errors = registry
+	}
+}
+
+// ErrorDecoder turns an error response into a structured error, typically by
+// decoding e.Body. Returning nil falls back to the ErrorRegistry and then to
+// e itself.
+type ErrorDecoder func(e *RestHttpError) error
+
+// WithErrorDecoder installs decoder for all error responses of the client.
+func WithErrorDecoder(decoder ErrorDecoder) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.errorDecoder = decoder
+	}
+}
+
+// JSONErrorDecoder decodes JSON error bodies into the error returned by
+// target, which must be a pointer:
+//
+//	resthttp.WithErrorDecoder(resthttp.JSONErrorDecoder(func() error { return &APIError{} }))
+//
+// Bodies that are not valid JSON are left to the default handling.
+func JSONErrorDecoder(target func() error) ErrorDecoder {
+	return func(e *RestHttpError) error {
+		if len(e.Body) == 0 {
+			return nil
+		}
+		decoded := target()
+		if json.Unmarshal(e.Body, decoded) != nil {
+			return nil
+		}
+		return decoded
+	}
+}
+
+// MappedError is returned when the ErrorDecoder or ErrorRegistry recognized
+// an error response. Both the application error and the underlying
+// RestHttpError are reachable through errors.Is and errors.As.
+type MappedError struct {
+	Err      error
+	Response *RestHttpError
+}
+
+func (e *MappedError) Error() string {
+	return e.Err.Error() + " (" + e.Response.Error() + ")"
+}
+
+func (e *MappedError) Unwrap() []error {
+	return []error{e.Err, e.Response}
+}
+
+// statusError builds the error returned for an unsuccessful response,
+// extracting the error code and message from JSON bodies and applying the
+// client's ErrorDecoder and ErrorRegistry.
+func (r *RestHttp) statusError(status int, statusText string, header http.Header, body []byte) error {
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	code, msg := extractErrorFields(body)
+	reason := strings.TrimSpace(strings.TrimPrefix(statusText, strconv.Itoa(status)))
+	if reason == "" {
+		reason = http.StatusText(status)
+	}
+	restErr := NewRestHttpError(status, reason, msg, code)
+	restErr.Header = header
+	restErr.Body = body
+	r.localize(restErr)
+
+	if r.errorDecoder != nil {
+		if decoded := r.errorDecoder(restErr); decoded != nil {
+			return &MappedError{Err: decoded, Response: restErr}
+		}
+	}
+
+	if r.errors != nil {
+		if build := r.errors.lookup(status, code); build != nil {
+			if mapped := build(restErr); mapped != nil {
+				return &MappedError{Err: mapped, Response: restErr}
+			}
+		}
+	}
+	return restErr
+}
+
+// responseError reads what is needed from resp to build its status error.
+func (r *RestHttp) responseError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return r.statusError(resp.StatusCode, resp.Status, resp.Header, body)
+}
+
+// extractErrorFields understands the common JSON error envelopes:
+// {"code": ..., "message": ...}, {"error": "...", "error_description": ...}
+// and {"error": {"code": ..., "message": ...}}.
+func extractErrorFields(body []byte) (string, string) {
+	var doc map[string]interface{}
+	if json.Unmarshal(body, &doc) != nil {
+		return "", ""
+	}
+	if nested, ok := doc["error"].(map[string]interface{}); ok {
+		doc = nested
+	}
+
+	code := firstField(doc, "code", "error_code", "errorCode", "error")
+	msg := firstField(doc, "message", "msg", "error_description", "detail", "title")
+	return code, msg
+}
+
+func firstField(doc map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		switch v := doc[name].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+// checkExpectedStatus returns the status error for resp when the caller listed
+// expected statuses and resp has none of them.
+func (r *RestHttp) checkExpectedStatus(resp *http.Response, ro *requestOptions) error {
+	if ro.expectedStatus == nil || ro.acceptsStatus(resp.StatusCode) {
+		return nil
+	}
+	return r.responseError(resp)
+}
@@ -0,0 +1,189 @@
+package resthttp
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormStyle selects how EncodeForm names nested fields.
+type FormStyle int
+
+const (
+	// BracketStyle produces Rails/PHP style names: user[name], items[0][id]
+	// and tags[] for lists of plain values.
+	BracketStyle FormStyle = iota
+	// DotStyle produces dotted names: user.name, items.0.id, with lists of
+	// plain values sent as repeated keys.
+	DotStyle
+)
+
+// EncodeForm flattens nested maps, structs and slices into url.Values so they
+// can be posted as a structured form with PostRequest or PutRequest. Struct
+// fields are named by their `form` tag ("name,omitempty", "-" to skip) and
+// fall back to the field name.
+func EncodeForm(v interface{}, style FormStyle) (url.Values, error) {
+	values := make(url.Values)
+	if err := encodeFormValue(values, "", reflect.ValueOf(v), style); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func encodeFormValue(values url.Values, name string, v reflect.Value, style FormStyle) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil
+	}
+
+	if s, ok, err := formatScalar(v); ok || err != nil {
+		if err != nil {
+			return fmt.Errorf("form field %s: %s", name, err)
+		}
+		if name == "" {
+			return fmt.Errorf("cannot encode %s as a form", v.Type())
+		}
+		values.Add(name, s)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("form field %s: map keys must be strings", name)
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			if err := encodeFormValue(values, nestedFormName(name, key.String(), style), v.MapIndex(key), style); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			key, omitEmpty := parseTag(field.Tag.Get("form"), field.Name)
+			if key == "-" {
+				continue
+			}
+			fv := v.Field(i)
+			if omitEmpty && fv.IsZero() {
+				continue
+			}
+			if err := encodeFormValue(values, nestedFormName(name, key, style), fv, style); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if name == "" {
+			return fmt.Errorf("cannot encode %s as a form", v.Type())
+		}
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			if isScalar(elem) {
+				key := name
+				if style == BracketStyle {
+					key += "[]"
+				}
+				if err := encodeFormValue(values, key, elem, style); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := encodeFormValue(values, nestedFormName(name, strconv.Itoa(i), style), elem, style); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("form field %s: unsupported type %s", name, v.Type())
+	}
+
+	return nil
+}
+
+func nestedFormName(prefix string, key string, style FormStyle) string {
+	if prefix == "" {
+		return key
+	}
+	if style == DotStyle {
+		return prefix + "." + key
+	}
+	return prefix + "[" + key + "]"
+}
+
+// parseTag splits a `form:"name,omitempty"` style tag.
+func parseTag(tag string, fallback string) (string, bool) {
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = fallback
+	}
+	omitEmpty := false
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func isScalar(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	_, ok, _ := formatScalar(v)
+	return ok
+}
+
+// formatScalar renders plain values as strings. ok is false for values that
+// need to be broken down further (maps, structs, slices).
+func formatScalar(v reflect.Value) (string, bool, error) {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339), true, nil
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), true, err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true, nil
+	}
+
+	return "", false, nil
+}
@@ -0,0 +1,97 @@
+package resthttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultGraphQLPath is where GraphQL looks for the endpoint, relative to
+// BaseURL, unless WithGraphQLPath says otherwise.
+const DefaultGraphQLPath = "/graphql"
+
+func WithGraphQLPath(path string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.graphQLPath = path
+	}
+}
+
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type GraphQLErrorItem struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []GraphQLLocation      `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLError carries the errors array of a GraphQL response. Data decoded
+// alongside it may be partial.
+type GraphQLError struct {
+	Errors []GraphQLErrorItem
+}
+
+func (e *GraphQLError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, item := range e.Errors {
+		messages = append(messages, item.Message)
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// GraphQL posts query and variables in the standard envelope and decodes the
+// data member into out (which may be nil).
+func (r *RestHttp) GraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}, opts ...RequestOption) error {
+	payload, err := json.Marshal(struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables,omitempty"`
+	}{query, variables})
+	if err != nil {
+		return err
+	}
+
+	path := r.graphQLPath
+	if path == "" {
+		path = DefaultGraphQLPath
+	}
+	url := r.baseURL() + "/" + strings.TrimLeft(path, "/")
+
+	opts = append([]RequestOption{
+		WithRequestHeader("Content-Type", "application/json"),
+		WithRequestHeader("Accept", "application/graphql-response+json, application/json"),
+	}, opts...)
+	resp, err := r.execute(ctx, "POST", url, bytes.NewReader(payload), opts)
+	if err != nil {
+		return err
+	}
+
+	var envelope struct {
+		Data   json.RawMessage    `json:"data"`
+		Errors []GraphQLErrorItem `json:"errors"`
+	}
+	if err := json.Unmarshal(resp.Body, &envelope); err != nil {
+		if resp.failed() {
+			return r.statusError(resp.StatusCode, resp.Status, resp.Header, resp.Body)
+		}
+		return fmt.Errorf("could not decode graphql response: %s", err)
+	}
+
+	if out != nil && len(envelope.Data) > 0 && string(envelope.Data) != "null" {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return fmt.Errorf("could not decode graphql data: %s", err)
+		}
+	}
+
+	if len(envelope.Errors) > 0 {
+		return &GraphQLError{Errors: envelope.Errors}
+	}
+	if resp.failed() {
+		return r.statusError(resp.StatusCode, resp.Status, resp.Header, resp.Body)
+	}
+	return nil
+}
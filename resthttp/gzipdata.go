@@ -0,0 +1,164 @@
+package resthttp
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// GetGzipJSON fetches a gzip compressed JSON document, such as a .json.gz
+// export, and decodes it into out while it streams in.
+func (r *RestHttp) GetGzipJSON(ctx context.Context, container string, resource string, out interface{}, opts ...RequestOption) error {
+	body, err := r.openGzip(ctx, container, resource, nil, opts)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := json.NewDecoder(body).Decode(out); err != nil {
+		return fmt.Errorf("could not decode %s: %s", resource, err)
+	}
+	return nil
+}
+
+// GetGzipCSV streams the records of a gzip compressed CSV file, such as a
+// .csv.gz export, to fn. Returning an error from fn stops reading and is
+// returned as is.
+func (r *RestHttp) GetGzipCSV(ctx context.Context, container string, resource string, fn func(record []string) error, opts ...RequestOption) error {
+	body, err := r.openGzip(ctx, container, resource, nil, opts)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	reader := csv.NewReader(body)
+	reader.ReuseRecord = true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read %s: %s", resource, err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// DownloadAndDecompress downloads a gzip compressed file and stores it
+// decompressed at savePath, which defaults to the resource name without its
+// .gz suffix. The download checks configured by WithDownloadChecks run on the
+// decompressed file.
+func (r *RestHttp) DownloadAndDecompress(container string, resource string, savePath string, queryItems url.Values, opts ...RequestOption) error {
+	ro := newRequestOptions(opts)
+	resource = strings.ReplaceAll(resource, "\\", "/")
+	if savePath == "" {
+		savePath = strings.TrimSuffix(path.Base(resource), ".gz")
+	}
+
+	body, err := r.openGzip(context.Background(), container, resource, queryItems, opts)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	size, err := r.saveDownload(body, savePath, body.header, ro)
+	if err != nil {
+		return err
+	}
+	r.log(LogDebug, "downloaded and decompressed file", "bytes", size, "path", savePath)
+	return nil
+}
+
+// gzipBody is a decompressing view of a response body.
+type gzipBody struct {
+	io.Reader
+	header  http.Header
+	closers []io.Closer
+}
+
+func (b *gzipBody) Close() error {
+	var first error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if err := b.closers[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// openGzip GETs a resource and returns its body with every gzip layer
+// removed: the file's own compression as well as a gzip Content-Encoding the
+// server may have added on top.
+func (r *RestHttp) openGzip(ctx context.Context, container string, resource string, queryItems url.Values, opts []RequestOption) (*gzipBody, error) {
+	ro := newRequestOptions(opts)
+	ro.keepEncoded = true
+
+	url := r.MakeURL(container, resource, queryItems)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/gzip, application/x-gzip, */*")
+	req.Header.Set("Accept-Encoding", r.acceptEncoding())
+
+	if err := r.prepareRequest(req, ro); err != nil {
+		return nil, err
+	}
+
+	resp, err := r.do(req, ro)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.debugEnabled() {
+		r.printRequest("GET", resp.Request.URL.String(), req.Header, nil)
+	}
+
+	if err := r.handleResponse(resp, ro); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	encoding := strings.ToLower(resp.Header.Get("Content-Encoding"))
+	if encoding != "" && encoding != "identity" && encoding != "gzip" && encoding != "x-gzip" {
+		if err := r.decodeResponse(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	body := &gzipBody{header: resp.Header, closers: []io.Closer{resp.Body}}
+	var reader io.Reader = resp.Body
+	for layer := 0; layer < 2; layer++ {
+		buffered := bufio.NewReader(reader)
+		magic, _ := buffered.Peek(2)
+		if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+			reader = buffered
+			break
+		}
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			body.Close()
+			return nil, fmt.Errorf("could not decompress %s: %s", resource, err)
+		}
+		body.closers = append(body.closers, gz)
+		reader = gz
+	}
+	if len(body.closers) == 1 {
+		body.Close()
+		return nil, fmt.Errorf("%s is not gzip compressed", resource)
+	}
+	body.Reader = reader
+	return body, nil
+}
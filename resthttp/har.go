@@ -0,0 +1,334 @@
+package resthttp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// HARRecorder captures the client's traffic and exports it as a HAR 1.2
+// archive. Recording can be started and stopped while requests are running.
+type HARRecorder struct {
+	// MaxBodySize caps the request and response body bytes kept per entry;
+	// zero keeps DefaultLogBodyLimit bytes, negative keeps none.
+	MaxBodySize int
+	// MaxEntries caps the entries kept, dropping the oldest; zero keeps
+	// DefaultHAREntries, negative keeps all.
+	MaxEntries int
+
+	enabled atomic.Bool
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// DefaultHAREntries is the number of entries a HARRecorder keeps by default.
+const DefaultHAREntries = 1000
+
+// NewHARRecorder returns a recorder that is already recording.
+func NewHARRecorder(maxBodySize int) *HARRecorder {
+	h := &HARRecorder{MaxBodySize: maxBodySize}
+	h.Start()
+	return h
+}
+
+// WithHARRecorder records all requests made by the client into h.
+func WithHARRecorder(h *HARRecorder) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.har = h
+	}
+}
+
+func (h *HARRecorder) Start() {
+	h.enabled.Store(true)
+}
+
+func (h *HARRecorder) Stop() {
+	h.enabled.Store(false)
+}
+
+func (h *HARRecorder) Recording() bool {
+	return h.enabled.Load()
+}
+
+// Len returns the number of recorded entries.
+func (h *HARRecorder) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+// Reset drops all recorded entries.
+func (h *HARRecorder) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = nil
+}
+
+// WriteTo writes the recorded entries as a HAR document.
+func (h *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	h.mu.Lock()
+	entries := append([]harEntry{}, h.entries...)
+	h.mu.Unlock()
+
+	doc := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "resthttp", Version: Version},
+		Entries: entries,
+	}}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+func (h *HARRecorder) add(entry harEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry)
+	max := h.MaxEntries
+	if max == 0 {
+		max = DefaultHAREntries
+	}
+	if max > 0 && len(h.entries) > max {
+		// Copy instead of reslicing so dropped entries can be collected.
+		h.entries = append(h.entries[:0:0], h.entries[len(h.entries)-max:]...)
+	}
+}
+
+func (h *HARRecorder) bodyLimit() int {
+	if h.MaxBodySize == 0 {
+		return DefaultLogBodyLimit
+	}
+	return h.MaxBodySize
+}
+
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	out := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			out = append(out, harNameValue{Name: name, Value: value})
+		}
+	}
+	return out
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// harText renders a captured body, base64 encoding anything that is not
+// valid UTF-8.
+func harText(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// recordRequest starts a HAR entry for req. The returned function completes
+// it with the response, or with the transport error when resp is nil.
+func (r *RestHttp) recordRequest(req *http.Request) (func(resp *http.Response, err error), error) {
+	h := r.har
+	if h == nil || !h.Recording() {
+		return nil, nil
+	}
+	limit := h.bodyLimit()
+
+	entry := harEntry{
+		StartedDateTime: time.Now().Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         r.redactedURL(req.URL),
+			HTTPVersion: req.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(r.redactHeader(req.Header)),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    req.ContentLength,
+		},
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			if r.redactedQueryParam(name) {
+				value = redacted
+			}
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if limit >= 0 && req.Body != nil && req.Body != http.NoBody {
+		body, err := peekBody(req)
+		if err != nil {
+			return nil, err
+		}
+		entry.Request.BodySize = int64(len(body))
+		post := &harPostData{MimeType: req.Header.Get("Content-Type")}
+		if len(body) > limit {
+			body = body[:limit]
+			// Do not let a rune cut in half make the text look binary.
+			for i := 0; i < utf8.UTFMax && !utf8.Valid(body); i++ {
+				body = body[:len(body)-1]
+			}
+			post.Comment = "truncated"
+		}
+		// HAR has no encoding for request bodies, so binary ones are left
+		// out rather than stored as unmarked base64.
+		if text, encoding := harText(body); encoding == "" {
+			post.Text = text
+		} else {
+			post.Comment = "binary body not captured"
+		}
+		entry.Request.PostData = post
+	}
+
+	start := time.Now()
+	return func(resp *http.Response, err error) {
+		wait := time.Since(start)
+		entry.Timings.Wait = milliseconds(wait)
+		if err != nil {
+			entry.Time = milliseconds(wait)
+			entry.Comment = err.Error()
+			entry.Response = harResponse{
+				Cookies:     []harNameValue{},
+				Headers:     []harNameValue{},
+				HeadersSize: -1,
+				BodySize:    -1,
+			}
+			h.add(entry)
+			return
+		}
+
+		entry.Response = harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)+" "),
+			HTTPVersion: resp.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(r.redactHeader(resp.Header)),
+			Content:     harContent{MimeType: resp.Header.Get("Content-Type")},
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+		}
+		resp.Body = &harBody{ReadCloser: resp.Body, limit: limit, done: func(body []byte, total int64) {
+			entry.Timings.Receive = milliseconds(time.Since(start) - wait)
+			entry.Time = entry.Timings.Send + entry.Timings.Wait + entry.Timings.Receive
+			entry.Response.BodySize = total
+			entry.Response.Content.Size = total
+			entry.Response.Content.Text, entry.Response.Content.Encoding = harText(body)
+			if int64(len(body)) < total {
+				entry.Response.Content.Comment = "truncated"
+			}
+			h.add(entry)
+		}}
+	}, nil
+}
+
+// harBody captures up to limit bytes of a response body and completes the
+// HAR entry when it is closed.
+type harBody struct {
+	io.ReadCloser
+	limit int
+	buf   bytes.Buffer
+	total int64
+	done  func(body []byte, total int64)
+	once  sync.Once
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.buf.Write(p[:room])
+	}
+	b.total += int64(n)
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	b.once.Do(func() { b.done(b.buf.Bytes(), b.total) })
+	return b.ReadCloser.Close()
+}
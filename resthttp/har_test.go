@@ -0,0 +1,80 @@
+package resthttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHARRequestBodies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		body        []byte
+		wantText    string
+		wantComment string
+	}{
+		{"text", []byte(`{"a":1}`), `{"a":1}`, ""},
+		{"binary", []byte{0xff, 0xfe, 0x00, 0x01}, "", "binary body not captured"},
+		{"truncated rune", []byte("abcdefghijklmnoé"), "abcdefghijklmno", "truncated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			har := NewHARRecorder(16)
+			r := NewRestHttp(srv.URL, WithHARRecorder(har))
+			if _, err := r.NewRequest().Container("items").Body(tt.body, "application/octet-stream").Post(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			if _, err := har.WriteTo(&out); err != nil {
+				t.Fatal(err)
+			}
+			var archive struct {
+				Log struct {
+					Entries []struct {
+						Request struct {
+							PostData struct {
+								Text     string
+								Comment  string
+								Encoding *string
+							}
+						}
+					}
+				}
+			}
+			if err := json.Unmarshal(out.Bytes(), &archive); err != nil {
+				t.Fatal(err)
+			}
+			if len(archive.Log.Entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(archive.Log.Entries))
+			}
+			post := archive.Log.Entries[0].Request.PostData
+			if post.Text != tt.wantText || post.Comment != tt.wantComment {
+				t.Errorf("got text %q comment %q, want %q and %q", post.Text, post.Comment, tt.wantText, tt.wantComment)
+			}
+		})
+	}
+}
+
+func TestHARMaxEntries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+
+	har := NewHARRecorder(0)
+	har.MaxEntries = 3
+	r := NewRestHttp(srv.URL, WithHARRecorder(har))
+	for i := 0; i < 5; i++ {
+		if _, err := r.GetRequest("items", "1", nil, "", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := har.Len(); n != 3 {
+		t.Errorf("recorder holds %d entries, want 3", n)
+	}
+}
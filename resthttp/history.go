@@ -0,0 +1,85 @@
+package resthttp
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HistoryEntry describes one request sent by the client. Retries are
+// recorded as requests of their own.
+type HistoryEntry struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	URL      string        `json:"url"`
+	Status   int           `json:"status,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// WithHistory keeps the last size requests in memory for History.
+func WithHistory(size int) func(*RestHttp) {
+	return func(r *RestHttp) {
+		if size > 0 {
+			r.history = &requestHistory{size: size}
+		} else {
+			r.history = nil
+		}
+	}
+}
+
+// History returns the recorded requests, oldest first, or nil unless the
+// client was created with WithHistory.
+func (r *RestHttp) History() []HistoryEntry {
+	if r.history == nil {
+		return nil
+	}
+	return r.history.entries()
+}
+
+// requestHistory is a ring of the most recent requests.
+type requestHistory struct {
+	mu   sync.Mutex
+	size int
+	ring []HistoryEntry
+	next int
+}
+
+func (h *requestHistory) add(entry HistoryEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.ring) < h.size {
+		h.ring = append(h.ring, entry)
+		return
+	}
+	h.ring[h.next] = entry
+	h.next = (h.next + 1) % h.size
+}
+
+func (h *requestHistory) entries() []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]HistoryEntry, 0, len(h.ring))
+	out = append(out, h.ring[h.next:]...)
+	return append(out, h.ring[:h.next]...)
+}
+
+// remember adds a finished request to the history, if one is kept.
+func (r *RestHttp) remember(req *http.Request, resp *http.Response, start time.Time, err error) {
+	if r.history == nil {
+		return
+	}
+	entry := HistoryEntry{
+		Time:     start,
+		Method:   req.Method,
+		URL:      r.redactedURL(req.URL),
+		Duration: time.Since(start),
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+	}
+	if err != nil {
+		entry.Error = r.redactedError(req, err)
+	}
+	r.history.add(entry)
+}
@@ -0,0 +1,77 @@
+package resthttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHistoryRedactsQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		options []func(*RestHttp)
+		query   url.Values
+		opts    []RequestOption
+		secret  string
+	}{
+		{
+			name:    "api key option",
+			options: []func(*RestHttp){WithAPIKey("tenant_key", "s3cret", APIKeyInQuery)},
+			secret:  "s3cret",
+		},
+		{
+			name:   "per-request api key",
+			opts:   []RequestOption{WithAuth(APIKeyAuth{Name: "api_key", Value: "s3cret", In: APIKeyInQuery})},
+			secret: "s3cret",
+		},
+		{
+			name:    "configured name",
+			options: []func(*RestHttp){WithRedactedQueryParams("sig")},
+			query:   url.Values{"sig": {"s3cret"}},
+			secret:  "s3cret",
+		},
+		{
+			name:    "redacted header name",
+			options: []func(*RestHttp){WithRedactedHeaders("X-Session")},
+			query:   url.Values{"x-session": {"s3cret"}},
+			secret:  "s3cret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRestHttp(srv.URL, append(tt.options, WithHistory(4))...)
+			if _, err := r.GetRequest("items", "1", tt.query, "", false, tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			history := r.History()
+			if len(history) != 1 {
+				t.Fatalf("got %d history entries, want 1", len(history))
+			}
+			if strings.Contains(history[0].URL, tt.secret) || !strings.Contains(history[0].URL, url.QueryEscape(redacted)) {
+				t.Errorf("history URL %s is not redacted", history[0].URL)
+			}
+		})
+	}
+}
+
+func TestHistoryRedactsErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	srv.Close()
+
+	r := NewRestHttp(srv.URL, WithHistory(4))
+	if _, err := r.GetRequest("items", "1", url.Values{"token": {"s3cret"}}, "", false); err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+	history := r.History()
+	if len(history) != 1 || history[0].Error == "" {
+		t.Fatalf("got %+v, want one failed request", history)
+	}
+	if strings.Contains(history[0].Error, "s3cret") {
+		t.Errorf("history error %q contains the token", history[0].Error)
+	}
+}
@@ -0,0 +1,106 @@
+package resthttp
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResponseHook is called with every response received by the client, before
+// the body is read. Hooks may adjust the client for subsequent requests.
+type ResponseHook func(r *RestHttp, resp *http.Response)
+
+// PollIntervalHook updates PollInterval from the named response header
+// (typically X-Poll-Interval). The value may be a number of seconds or a Go
+// duration string such as "1m30s".
+func PollIntervalHook(header string) ResponseHook {
+	return func(r *RestHttp, resp *http.Response) {
+		value := strings.TrimSpace(resp.Header.Get(header))
+		if value == "" {
+			return
+		}
+
+		if interval, ok := parseInterval(value); ok {
+			r.setPollInterval(interval)
+		}
+	}
+}
+
+func parseInterval(value string) (time.Duration, bool) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0, false
+		}
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		return 0, false
+	}
+	return interval, true
+}
+
+// MovedBaseURLHook switches BaseURL when the server reports that the API has
+// moved for good. The named header (for example X-API-Deprecated-Use) is
+// expected to carry the new absolute base URL; pass "" to only react to
+// permanent redirects. When a request was answered with a chain of 301/308
+// redirects, the new base URL is derived from the final request URL.
+func MovedBaseURLHook(header string) ResponseHook {
+	return func(r *RestHttp, resp *http.Response) {
+		if header != "" {
+			if value := strings.TrimSpace(resp.Header.Get(header)); value != "" {
+				if u, err := url.Parse(value); err == nil && u.IsAbs() {
+					r.SetBaseURL(value)
+					return
+				}
+			}
+		}
+
+		if newBase, ok := movedBaseURL(r.baseURL(), resp); ok {
+			r.SetBaseURL(newBase)
+		}
+	}
+}
+
+func movedBaseURL(baseURL string, resp *http.Response) (string, bool) {
+	final := resp.Request
+	if final == nil || final.Response == nil {
+		return "", false
+	}
+
+	first := final
+	for first.Response != nil {
+		status := first.Response.StatusCode
+		if status != http.StatusMovedPermanently && status != http.StatusPermanentRedirect {
+			return "", false
+		}
+		first = first.Response.Request
+	}
+
+	oldURL := urlWithoutQuery(first.URL)
+	newURL := urlWithoutQuery(final.URL)
+	if !strings.HasPrefix(oldURL, baseURL) {
+		return "", false
+	}
+
+	suffix := oldURL[len(baseURL):]
+	if !strings.HasSuffix(newURL, suffix) {
+		return "", false
+	}
+
+	newBase := strings.TrimRight(newURL[:len(newURL)-len(suffix)], "/")
+	if newBase == "" || newBase == baseURL {
+		return "", false
+	}
+	return newBase, true
+}
+
+func urlWithoutQuery(u *url.URL) string {
+	stripped := *u
+	stripped.RawQuery = ""
+	stripped.Fragment = ""
+	return stripped.String()
+}
@@ -0,0 +1,40 @@
+package resthttp
+
+// MessageCatalog turns API error codes into messages fit for the UI. The
+// server's message is passed along for catalogs that key on it instead.
+type MessageCatalog interface {
+	Translate(code string, serverMsg string) (string, bool)
+}
+
+// MessageCatalogFunc adapts a function to MessageCatalog.
+type MessageCatalogFunc func(code string, serverMsg string) (string, bool)
+
+func (f MessageCatalogFunc) Translate(code string, serverMsg string) (string, bool) {
+	return f(code, serverMsg)
+}
+
+// MapCatalog is a MessageCatalog keyed by error code.
+type MapCatalog map[string]string
+
+func (c MapCatalog) Translate(code string, serverMsg string) (string, bool) {
+	msg, ok := c[code]
+	return msg, ok
+}
+
+// WithMessageCatalog localizes RestHttpError messages through catalog. The
+// untranslated server message stays available in RestHttpError.ServerMsg.
+func WithMessageCatalog(catalog MessageCatalog) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.catalog = catalog
+	}
+}
+
+func (r *RestHttp) localize(e *RestHttpError) {
+	if r.catalog == nil {
+		return
+	}
+	if msg, ok := r.catalog.Translate(e.Code, e.Msg); ok {
+		e.ServerMsg = e.Msg
+		e.Msg = msg
+	}
+}
@@ -0,0 +1,46 @@
+package resthttp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// IdempotencyKeyHeader carries the key that lets servers such as Stripe
+// deduplicate repeated POST and PATCH requests.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// WithIdempotencyKeys attaches a generated Idempotency-Key to every POST and
+// PATCH request that has none. Requests carrying a key keep it across retry
+// attempts and are retried by the RetryPolicy like idempotent methods.
+func WithIdempotencyKeys() func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.idempotencyKeys = true
+	}
+}
+
+// WithIdempotencyKey sends key as this request's Idempotency-Key, making it
+// eligible for retries.
+func WithIdempotencyKey(key string) RequestOption {
+	return WithRequestHeader(IdempotencyKeyHeader, key)
+}
+
+// NewIdempotencyKey returns a random UUID (version 4).
+func NewIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("could not generate idempotency key: %s", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func (r *RestHttp) setIdempotencyKey(req *http.Request) {
+	if !r.idempotencyKeys || req.Header.Get(IdempotencyKeyHeader) != "" {
+		return
+	}
+	if req.Method == "POST" || req.Method == "PATCH" {
+		req.Header.Set(IdempotencyKeyHeader, NewIdempotencyKey())
+	}
+}
@@ -0,0 +1,70 @@
+package resthttp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// WithTLSKeyLog writes the TLS session secrets of every connection to w in
+// NSS key log format, which Wireshark uses to decrypt captured traffic.
+//
+// WARNING: anyone holding the output can decrypt the traffic it covers,
+// including credentials. Only enable it while debugging, never by default in
+// production builds.
+func WithTLSKeyLog(w io.Writer) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.keyLog = w
+		warnKeyLog("")
+	}
+}
+
+// WithTLSKeyLogFile is WithTLSKeyLog appending to the file at path, the
+// format expected in SSLKEYLOGFILE. The file is created with mode 0600 when
+// the first request is prepared, which fails if it cannot be opened; the
+// same warning applies.
+func WithTLSKeyLogFile(path string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.keyLog = &keyLogFile{path: path}
+		warnKeyLog(" to " + path)
+	}
+}
+
+// keyLogWarnings receives the key logging warning. It is written whatever
+// the log level, so that key logging is never enabled silently.
+var keyLogWarnings io.Writer = os.Stderr
+
+func warnKeyLog(destination string) {
+	fmt.Fprintf(keyLogWarnings, "resthttp: WARNING: TLS key logging is enabled%s, captured traffic can be decrypted\n", destination)
+}
+
+// keyLogFile opens its file on first use so that merely configuring a
+// client does not touch the file system.
+type keyLogFile struct {
+	path string
+	once sync.Once
+	mu   sync.Mutex
+	file *os.File
+	err  error
+}
+
+func (k *keyLogFile) open() error {
+	k.once.Do(func() {
+		k.file, k.err = os.OpenFile(k.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if k.err != nil {
+			k.err = fmt.Errorf("could not open TLS key log: %s", k.err)
+		}
+	})
+	return k.err
+}
+
+func (k *keyLogFile) Write(p []byte) (int, error) {
+	if err := k.open(); err != nil {
+		return 0, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.file.Write(p)
+}
@@ -0,0 +1,45 @@
+package resthttp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTLSKeyLog(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+
+	var warnings bytes.Buffer
+	keyLogWarnings = &warnings
+	defer func() { keyLogWarnings = os.Stderr }()
+
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		option  func(*RestHttp)
+		wantErr bool
+	}{
+		{"writer", WithTLSKeyLog(&bytes.Buffer{}), false},
+		{"file", WithTLSKeyLogFile(filepath.Join(dir, "keys.log")), false},
+		{"unopenable file", WithTLSKeyLogFile(filepath.Join(dir, "missing", "keys.log")), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings.Reset()
+			r := NewRestHttp(srv.URL, tt.option)
+			r.VerifySSL = false
+			if !strings.Contains(warnings.String(), "TLS key logging is enabled") {
+				t.Errorf("no warning written, got %q", warnings.String())
+			}
+			_, err := r.GetRequest("items", "1", nil, "", false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package resthttp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxHeaderBytes is the request header budget many gateways enforce.
+const DefaultMaxHeaderBytes = 8 << 10
+
+// HeaderLimitError is returned, before anything is sent, when a request
+// exceeds the limits configured with WithHeaderLimits.
+type HeaderLimitError struct {
+	// Header is the offending header, or empty when the total was exceeded.
+	Header string
+	Size   int
+	Limit  int
+}
+
+func (e *HeaderLimitError) Error() string {
+	if e.Header != "" {
+		return fmt.Sprintf("request header %s is %d bytes, limit is %d", e.Header, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("request headers are %d bytes, limit is %d", e.Size, e.Limit)
+}
+
+// WithHeaderLimits rejects requests whose request line plus headers exceed
+// maxTotal bytes, or with a single header line longer than maxPerHeader
+// bytes. Zero disables the respective check.
+func WithHeaderLimits(maxTotal int, maxPerHeader int) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.maxHeaderBytes = maxTotal
+		r.maxHeaderLineBytes = maxPerHeader
+	}
+}
+
+func (r *RestHttp) checkHeaderLimits(req *http.Request) error {
+	if r.maxHeaderBytes <= 0 && r.maxHeaderLineBytes <= 0 {
+		return nil
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	// Request line and Host header, as written on the wire.
+	total := len(req.Method) + 1 + len(req.URL.RequestURI()) + len(" HTTP/1.1\r\n")
+	total += len("Host: ") + len(host) + 2
+
+	for key, values := range req.Header {
+		for _, value := range values {
+			line := len(key) + 2 + len(value) + 2
+			if r.maxHeaderLineBytes > 0 && line > r.maxHeaderLineBytes {
+				return &HeaderLimitError{Header: key, Size: line, Limit: r.maxHeaderLineBytes}
+			}
+			total += line
+		}
+	}
+	total += 2
+
+	if r.maxHeaderBytes > 0 && total > r.maxHeaderBytes {
+		return &HeaderLimitError{Size: total, Limit: r.maxHeaderBytes}
+	}
+	return nil
+}
+
+// ErrResponseTooLarge is returned instead of a response body that exceeds
+// the limit set with WithMaxResponseBytes or WithResponseLimit.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// WithMaxResponseBytes limits the response bodies the client reads into
+// memory to n bytes; longer bodies fail with ErrResponseTooLarge. Downloads
+// to files or writers and streamed responses are not limited.
+func WithMaxResponseBytes(n int64) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.maxResponseBytes = n
+	}
+}
+
+// WithResponseLimit overrides WithMaxResponseBytes for this request. A
+// negative n removes the limit.
+func WithResponseLimit(n int64) RequestOption {
+	return func(ro *requestOptions) {
+		ro.maxResponseBytes = n
+	}
+}
+
+func (r *RestHttp) responseLimit(ro *requestOptions) int64 {
+	if ro != nil && ro.maxResponseBytes != 0 {
+		return ro.maxResponseBytes
+	}
+	return r.maxResponseBytes
+}
+
+// readBody reads the whole response body, stopping one byte past the
+// response limit.
+func (r *RestHttp) readBody(resp *http.Response, ro *requestOptions) ([]byte, error) {
+	limit := r.responseLimit(ro)
+	if limit <= 0 {
+		return readAll(resp.Body, resp.ContentLength)
+	}
+
+	tooLarge := func() error {
+		return fmt.Errorf("%s %s: %w, limit is %d bytes", resp.Request.Method, resp.Request.URL.Redacted(), ErrResponseTooLarge, limit)
+	}
+	if resp.ContentLength > limit {
+		return nil, tooLarge()
+	}
+	body, err := readAll(io.LimitReader(resp.Body, limit+1), resp.ContentLength)
+	if err == nil && int64(len(body)) > limit {
+		return nil, tooLarge()
+	}
+	return body, err
+}
@@ -0,0 +1,312 @@
+package resthttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger receives the client's log output. Its method set matches
+// *slog.Logger, which can be passed to WithLogger directly.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// DefaultLogBodyLimit is how many bytes of a request or response body are
+// logged before the rest is elided.
+const DefaultLogBodyLimit = 4 << 10
+
+const redacted = "[REDACTED]"
+
+var defaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// defaultRedactedQuery lists query parameters commonly carrying credentials.
+var defaultRedactedQuery = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"key",
+	"password",
+	"signature",
+	"token",
+}
+
+// WithLogger routes log output to logger instead of stdout.
+func WithLogger(logger Logger) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.logger = logger
+	}
+}
+
+// WithLogBodyLimit sets how many body bytes are logged; a negative limit
+// disables body logging.
+func WithLogBodyLimit(limit int) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.logBodyLimit = limit
+	}
+}
+
+// WithRedactedHeaders adds header names whose values are never logged, on
+// top of Authorization, Proxy-Authorization, Cookie, Set-Cookie and
+// X-Api-Key.
+func WithRedactedHeaders(names ...string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		for _, name := range names {
+			r.redactHeaders = append(r.redactHeaders, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+// WithRedactedQueryParams adds query parameter names whose values are
+// masked in logs, History and HAR recordings, on top of common credential
+// names like api_key and access_token, the parameter of a WithAPIKey query
+// key and the names given to WithRedactedHeaders.
+func WithRedactedQueryParams(names ...string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.redactQuery = append(r.redactQuery, names...)
+	}
+}
+
+func (r *RestHttp) redactedQueryParam(name string) bool {
+	for _, list := range [][]string{defaultRedactedQuery, r.redactQuery, r.redactHeaders} {
+		for _, redacted := range list {
+			if strings.EqualFold(name, redacted) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactedURL renders u with its password and credential query parameters
+// masked.
+func (r *RestHttp) redactedURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	query := u.Query()
+	changed := false
+	for name, values := range query {
+		if r.redactedQueryParam(name) {
+			for i := range values {
+				values[i] = redacted
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return u.Redacted()
+	}
+	masked := *u
+	masked.RawQuery = query.Encode()
+	return masked.Redacted()
+}
+
+// redactedURLString is redactedURL for URLs kept as strings.
+func (r *RestHttp) redactedURLString(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return r.redactedURL(u)
+}
+
+// redactedError renders err with the request URL, which url.Error and
+// others embed, masked.
+func (r *RestHttp) redactedError(req *http.Request, err error) string {
+	msg := err.Error()
+	if req.URL.RawQuery == "" {
+		return msg
+	}
+	masked, _ := url.Parse(r.redactedURL(req.URL))
+	if masked == nil || masked.RawQuery == req.URL.RawQuery {
+		return msg
+	}
+	return strings.ReplaceAll(msg, req.URL.RawQuery, masked.RawQuery)
+}
+
+// stdLogger writes "level msg key=value ..." lines, the client's output when
+// no Logger is configured.
+type stdLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var defaultLogger = &stdLogger{w: os.Stdout}
+
+func (l *stdLogger) Debug(msg string, args ...any) { l.write("DEBUG", msg, args) }
+func (l *stdLogger) Info(msg string, args ...any)  { l.write("INFO", msg, args) }
+func (l *stdLogger) Warn(msg string, args ...any)  { l.write("WARN", msg, args) }
+func (l *stdLogger) Error(msg string, args ...any) { l.write("ERROR", msg, args) }
+
+func (l *stdLogger) write(level string, msg string, args []any) {
+	var line strings.Builder
+	line.WriteString(level)
+	line.WriteString(" ")
+	line.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		key := fmt.Sprint(args[i])
+		var value any = "!MISSING"
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+		fmt.Fprintf(&line, " %s=%s", key, formatLogValue(value))
+	}
+	line.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, line.String())
+}
+
+func formatLogValue(value any) string {
+	switch v := value.(type) {
+	case http.Header:
+		parts := make([]string, 0, len(v))
+		for key, values := range v {
+			parts = append(parts, key+": "+strings.Join(values, ", "))
+		}
+		return fmt.Sprintf("%q", parts)
+	case string:
+		if v == "" || strings.ContainsAny(v, " \t\r\n\"=") {
+			return fmt.Sprintf("%q", v)
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (r *RestHttp) log(level LogLevel, msg string, args ...any) {
+	if level == LogOff || r.currentLogLevel() < level {
+		return
+	}
+	logger := r.logger
+	if logger == nil {
+		logger = defaultLogger
+	}
+	switch level {
+	case LogError:
+		logger.Error(msg, args...)
+	case LogInfo:
+		logger.Info(msg, args...)
+	default:
+		logger.Debug(msg, args...)
+	}
+}
+
+// redactHeader returns a copy of header with credentials replaced.
+func (r *RestHttp) redactHeader(header http.Header) http.Header {
+	out := header.Clone()
+	for _, name := range defaultRedactedHeaders {
+		if _, ok := out[name]; ok {
+			out[name] = []string{redacted}
+		}
+	}
+	for _, name := range r.redactHeaders {
+		if _, ok := out[name]; ok {
+			out[name] = []string{redacted}
+		}
+	}
+	return out
+}
+
+func (r *RestHttp) bodyLimit() int {
+	if r.logBodyLimit == 0 {
+		return DefaultLogBodyLimit
+	}
+	return r.logBodyLimit
+}
+
+// truncateBody renders at most the configured number of body bytes.
+func (r *RestHttp) truncateBody(body []byte) string {
+	limit := r.bodyLimit()
+	if limit < 0 {
+		return ""
+	}
+	if len(body) > limit {
+		return fmt.Sprintf("%s... (%d bytes total)", body[:limit], len(body))
+	}
+	return string(body)
+}
+
+func (r *RestHttp) printRequest(method string, url string, headers http.Header, body []byte) {
+	args := []any{"method", method, "url", r.redactedURLString(url), "headers", r.redactHeader(headers)}
+	if r.bodyLimit() >= 0 {
+		args = append(args, "body", r.truncateBody(body))
+	}
+	r.log(LogDebug, "request", args...)
+}
+
+// logResponse reports a completed exchange. At debug level the response body
+// is captured, up to the body limit, as the caller reads it and logged when
+// the body is closed.
+func (r *RestHttp) logResponse(req *http.Request, resp *http.Response, elapsed time.Duration, ro *requestOptions) {
+	level := r.currentLogLevel()
+	if level < LogInfo {
+		return
+	}
+	r.log(LogInfo, "response", "method", req.Method, "url", r.redactedURL(req.URL),
+		"status", resp.StatusCode, "elapsed", elapsed)
+	if level < LogDebug {
+		return
+	}
+
+	args := []any{"url", r.redactedURL(req.URL), "status", resp.Status, "headers", r.redactHeader(resp.Header)}
+	if ro.stream || ro.keepEncoded || r.bodyLimit() < 0 {
+		r.log(LogDebug, "response headers", args...)
+		return
+	}
+	resp.Body = &loggedBody{ReadCloser: resp.Body, client: r, limit: r.bodyLimit(), args: args}
+}
+
+type loggedBody struct {
+	io.ReadCloser
+	client *RestHttp
+	limit  int
+	args   []any
+	buf    bytes.Buffer
+	total  int
+	once   sync.Once
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.buf.Write(p[:room])
+	}
+	b.total += n
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	b.once.Do(func() {
+		body := b.buf.String()
+		if b.total > b.buf.Len() {
+			body = fmt.Sprintf("%s... (%d bytes read)", body, b.total)
+		}
+		b.client.log(LogDebug, "response", append(b.args, "body", body)...)
+	})
+	return b.ReadCloser.Close()
+}
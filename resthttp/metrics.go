@@ -0,0 +1,97 @@
+package resthttp
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetricLabels identify a request in Metrics. StatusClass is "2xx", "3xx",
+// "4xx" or "5xx", or "error" when no response was received.
+type MetricLabels struct {
+	Method      string
+	Host        string
+	StatusClass string
+}
+
+// Metrics receives measurements for every request the client sends. It is
+// shaped after Prometheus collectors: a gauge of requests in flight, and a
+// counter plus histograms fed by ObserveRequest.
+//
+// A Prometheus adapter typically looks like:
+//
+//	func (m promMetrics) InFlight(method, host string, delta int) {
+//		m.inFlight.WithLabelValues(method, host).Add(float64(delta))
+//	}
+//
+//	func (m promMetrics) ObserveRequest(l resthttp.MetricLabels, d time.Duration, size int64) {
+//		m.requests.WithLabelValues(l.Method, l.Host, l.StatusClass).Inc()
+//		m.duration.WithLabelValues(l.Method, l.Host, l.StatusClass).Observe(d.Seconds())
+//		m.size.WithLabelValues(l.Method, l.Host, l.StatusClass).Observe(float64(size))
+//	}
+type Metrics interface {
+	InFlight(method string, host string, delta int)
+	// ObserveRequest is called once the response body has been closed, with
+	// the time until the response headers arrived and the number of body
+	// bytes read.
+	ObserveRequest(labels MetricLabels, duration time.Duration, responseSize int64)
+}
+
+func WithMetrics(metrics Metrics) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.metrics = metrics
+	}
+}
+
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "error"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// measure reports req as in flight. The returned function completes the
+// measurement with the response, or with the transport error when resp is nil.
+func (r *RestHttp) measure(req *http.Request) func(resp *http.Response, err error) {
+	m := r.metrics
+	if m == nil {
+		return nil
+	}
+	method, host := req.Method, req.URL.Host
+	m.InFlight(method, host, 1)
+	start := time.Now()
+
+	return func(resp *http.Response, err error) {
+		elapsed := time.Since(start)
+		if err != nil {
+			m.InFlight(method, host, -1)
+			m.ObserveRequest(MetricLabels{Method: method, Host: host, StatusClass: "error"}, elapsed, 0)
+			return
+		}
+		labels := MetricLabels{Method: method, Host: host, StatusClass: statusClass(resp.StatusCode)}
+		resp.Body = &measuredBody{ReadCloser: resp.Body, done: func(size int64) {
+			m.InFlight(method, host, -1)
+			m.ObserveRequest(labels, elapsed, size)
+		}}
+	}
+}
+
+type measuredBody struct {
+	io.ReadCloser
+	size int64
+	done func(size int64)
+	once sync.Once
+}
+
+func (b *measuredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *measuredBody) Close() error {
+	b.once.Do(func() { b.done(b.size) })
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,97 @@
+package resthttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Migration moves a client from BaseURL to NewURL without a flag day: until
+// the Until deadline, requests go to NewURL first and are repeated against
+// BaseURL when the new endpoint cannot be reached or answers with one of the
+// FallbackOn statuses. After Until, requests go to NewURL only.
+//
+// Stats reports how many requests each side served; with WithMetrics every
+// attempt is also observed under its host.
+type Migration struct {
+	NewURL string
+	Until  time.Time
+	// FallbackOn defaults to 404.
+	FallbackOn []int
+}
+
+func WithBaseURLMigration(migration Migration) func(*RestHttp) {
+	return func(r *RestHttp) {
+		migration.NewURL = strings.TrimRight(migration.NewURL, "/")
+		r.migration = &migration
+	}
+}
+
+func (m *Migration) fallbackStatus(status int) bool {
+	statuses := m.FallbackOn
+	if statuses == nil {
+		statuses = []int{http.StatusNotFound}
+	}
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// migratedDo sends req to the new base URL and falls back to the old one
+// while the migration period lasts.
+func (r *RestHttp) migratedDo(req *http.Request, ro *requestOptions) (*http.Response, error) {
+	m := r.migration
+	base := r.baseURL()
+	urlStr := req.URL.String()
+	if m.NewURL == "" || !strings.HasPrefix(urlStr, base) {
+		return r.dispatch(req, ro)
+	}
+	target, err := url.Parse(m.NewURL + urlStr[len(base):])
+	if err != nil {
+		return nil, err
+	}
+
+	moved := req.Clone(req.Context())
+	moved.URL = target
+	moved.Host = target.Host
+	resp, err := r.dispatch(moved, ro)
+
+	fallback := time.Now().Before(m.Until) && req.Context().Err() == nil
+	if err != nil {
+		fallback = fallback && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	} else {
+		fallback = fallback && m.fallbackStatus(resp.StatusCode)
+	}
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if !fallback || !replayable {
+		r.count(func(s *clientStats) { s.migrationNew.Add(1) })
+		return resp, err
+	}
+
+	if resp != nil {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		r.log(LogInfo, "falling back to old base URL", "url", target.String(), "status", resp.StatusCode)
+	} else {
+		r.log(LogInfo, "falling back to old base URL", "url", target.String(), "error", err)
+	}
+
+	old := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		old.Body = body
+	}
+	r.count(func(s *clientStats) { s.migrationOld.Add(1) })
+	return r.dispatch(old, ro)
+}
@@ -0,0 +1,177 @@
+package resthttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Part is one part of a multipart/form-data body: a FormPart, JSONPart or
+// FilePart. Parts are written in the order given, so layouts that expect
+// metadata before the file content can be built:
+//
+//	client.UploadParts("images", "", []resthttp.Part{
+//		resthttp.JSONPart{Field: "metadata", Value: meta},
+//		resthttp.FilePart{Field: "file", Filename: "disk.qcow2", Reader: f},
+//	})
+type Part interface {
+	writePart(r *RestHttp, w *multipart.Writer) error
+}
+
+// FormPart is a plain form field. ContentType is only sent when set.
+type FormPart struct {
+	Field       string
+	Value       string
+	ContentType string
+}
+
+// JSONPart is a form field carrying Value encoded as JSON.
+type JSONPart struct {
+	Field string
+	Value interface{}
+}
+
+// FilePart is a file field. ContentType defaults to the type registered for
+// the extension of Filename, then to application/octet-stream. The upload
+// policy is applied to the content; readers other than *os.File are read
+// into memory for it.
+type FilePart struct {
+	Field       string
+	Filename    string
+	ContentType string
+	Reader      io.Reader
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func formDisposition(field string, filename string) string {
+	if filename == "" {
+		return fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(field))
+	}
+	return fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(field), quoteEscaper.Replace(filename))
+}
+
+func (p FormPart) writePart(r *RestHttp, w *multipart.Writer) error {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", formDisposition(p.Field, ""))
+	if p.ContentType != "" {
+		h.Set("Content-Type", p.ContentType)
+	}
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, p.Value)
+	return err
+}
+
+func (p JSONPart) writePart(r *RestHttp, w *multipart.Writer) error {
+	data, err := json.Marshal(p.Value)
+	if err != nil {
+		return fmt.Errorf("could not encode part %s: %s", p.Field, err)
+	}
+	return FormPart{Field: p.Field, Value: string(data), ContentType: "application/json"}.writePart(r, w)
+}
+
+func (p FilePart) writePart(r *RestHttp, w *multipart.Writer) error {
+	if p.Reader == nil {
+		return fmt.Errorf("file part %s has no content", p.Field)
+	}
+
+	content := p.Reader
+	if file, ok := content.(*os.File); ok {
+		if err := r.checkUpload(file, p.Filename); err != nil {
+			return err
+		}
+	} else if r.uploadPolicy != nil {
+		data, err := ioutil.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		if err := r.checkContent(p.Filename, data); err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+
+	contentType := p.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(p.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", formDisposition(p.Field, p.Filename))
+	h.Set("Content-Type", contentType)
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, content)
+	return err
+}
+
+// encodeMultipart writes parts into a multipart/form-data body and returns
+// it with its content type.
+func (r *RestHttp) encodeMultipart(parts []Part) ([]byte, string, error) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for _, part := range parts {
+		if err := part.writePart(r, writer); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+// UploadParts posts parts as a multipart/form-data body to the resource and
+// returns the response body.
+func (r *RestHttp) UploadParts(container string, resource string, parts []Part, opts ...RequestOption) ([]byte, error) {
+	ro := newRequestOptions(opts)
+	url := r.MakeURL(container, resource, nil)
+
+	body, contentType, err := r.encodeMultipart(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	if err := r.prepareRequest(req, ro); err != nil {
+		return nil, err
+	}
+
+	resp, err := r.do(req, ro)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if r.debugEnabled() {
+		r.printRequest("POST", resp.Request.URL.String(), req.Header, nil)
+	}
+
+	if err := r.handleResponse(resp, ro); err != nil {
+		return nil, err
+	}
+
+	return r.readBody(resp, ro)
+}
@@ -0,0 +1,248 @@
+package resthttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type OAuth2Token struct {
+	AccessToken string
+	TokenType   string
+	Expiry      time.Time
+}
+
+func (t *OAuth2Token) validFor(margin time.Duration) bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Add(margin).Before(t.Expiry))
+}
+
+// TokenCache shares OAuth2 tokens between every provider and client using
+// it, keyed by token endpoint, client, audience, scopes and tenant.
+// Concurrent requests for the same key wait for a single token fetch.
+type TokenCache struct {
+	mu      sync.Mutex
+	entries map[string]*tokenEntry
+
+	fetches atomic.Int64
+	hits    atomic.Int64
+	errors  atomic.Int64
+}
+
+type tokenEntry struct {
+	mu    sync.Mutex
+	token *OAuth2Token
+}
+
+type TokenCacheStats struct {
+	Fetches int64 `json:"fetches"`
+	Hits    int64 `json:"hits"`
+	Errors  int64 `json:"errors"`
+}
+
+func NewTokenCache() *TokenCache {
+	return &TokenCache{entries: make(map[string]*tokenEntry)}
+}
+
+// DefaultTokenCache is used by providers that do not set their own Cache.
+var DefaultTokenCache = NewTokenCache()
+
+// maxTokenBody bounds how much of a token endpoint response is read.
+const maxTokenBody = 1 << 20
+
+func (c *TokenCache) Stats() TokenCacheStats {
+	return TokenCacheStats{
+		Fetches: c.fetches.Load(),
+		Hits:    c.hits.Load(),
+		Errors:  c.errors.Load(),
+	}
+}
+
+func (c *TokenCache) entry(key string) *tokenEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &tokenEntry{}
+		c.entries[key] = e
+	}
+	return e
+}
+
+func (c *TokenCache) get(key string, margin time.Duration, fetch func() (*OAuth2Token, error)) (*OAuth2Token, error) {
+	e := c.entry(key)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.token.validFor(margin) {
+		c.hits.Add(1)
+		return e.token, nil
+	}
+
+	c.fetches.Add(1)
+	token, err := fetch()
+	if err != nil {
+		c.errors.Add(1)
+		return nil, err
+	}
+	e.token = token
+	return token, nil
+}
+
+func (c *TokenCache) invalidate(key string) {
+	e := c.entry(key)
+	e.mu.Lock()
+	e.token = nil
+	e.mu.Unlock()
+}
+
+// OAuth2ClientCredentials is an AuthProvider obtaining bearer tokens with the
+// client credentials grant.
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	Audience     string
+	Tenant       string
+
+	// Cache defaults to DefaultTokenCache.
+	Cache *TokenCache
+	// HTTPClient defaults to a client sharing the transport, and with it the
+	// TLS, proxy and DNS settings, of the RestHttp sending the request.
+	HTTPClient *http.Client
+	// EarlyExpiry refreshes tokens this long before they expire. Defaults to
+	// 30 seconds.
+	EarlyExpiry time.Duration
+}
+
+func (o *OAuth2ClientCredentials) cache() *TokenCache {
+	if o.Cache != nil {
+		return o.Cache
+	}
+	return DefaultTokenCache
+}
+
+func (o *OAuth2ClientCredentials) cacheKey() string {
+	scopes := append([]string(nil), o.Scopes...)
+	sort.Strings(scopes)
+	return strings.Join([]string{o.TokenURL, o.ClientID, o.Audience, strings.Join(scopes, " "), o.Tenant}, "\x00")
+}
+
+func (o *OAuth2ClientCredentials) Authenticate(req *http.Request) error {
+	token, err := o.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	tokenType := token.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	req.Header.Set("Authorization", tokenType+" "+token.AccessToken)
+	return nil
+}
+
+// Token returns a cached token or fetches a new one.
+func (o *OAuth2ClientCredentials) Token(ctx context.Context) (*OAuth2Token, error) {
+	margin := o.EarlyExpiry
+	if margin == 0 {
+		margin = 30 * time.Second
+	}
+	return o.cache().get(o.cacheKey(), margin, func() (*OAuth2Token, error) {
+		return o.fetch(ctx)
+	})
+}
+
+// Invalidate drops the cached token, e.g. after the API rejected it with 401.
+func (o *OAuth2ClientCredentials) Invalidate() {
+	o.cache().invalidate(o.cacheKey())
+}
+
+type authClientKey struct{}
+
+// withAuthClient prepares req for an AuthProvider, letting providers that
+// call out themselves use the client's transport.
+func (r *RestHttp) withAuthClient(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), authClientKey{}, r.createHttpClient()))
+}
+
+// authClient returns the client put into ctx by withAuthClient, or
+// http.DefaultClient for tokens requested outside of a request.
+func authClient(ctx context.Context) *http.Client {
+	if client, ok := ctx.Value(authClientKey{}).(*http.Client); ok {
+		return client
+	}
+	return http.DefaultClient
+}
+
+func (o *OAuth2ClientCredentials) fetch(ctx context.Context) (*OAuth2Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	if o.Audience != "" {
+		form.Set("audience", o.Audience)
+	}
+	if o.Tenant != "" {
+		form.Set("tenant", o.Tenant)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+
+	client := o.HTTPClient
+	if client == nil {
+		client = authClient(ctx)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxTokenBody))
+	if err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		AccessToken      string      `json:"access_token"`
+		TokenType        string      `json:"token_type"`
+		ExpiresIn        json.Number `json:"expires_in"`
+		Error            string      `json:"error"`
+		ErrorDescription string      `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil && resp.StatusCode < 300 {
+		return nil, fmt.Errorf("could not decode token response: %s", err)
+	}
+	if resp.StatusCode >= 300 || payload.Error != "" {
+		msg := payload.ErrorDescription
+		if msg == "" {
+			msg = payload.Error
+		}
+		return nil, NewRestHttpError(resp.StatusCode, http.StatusText(resp.StatusCode), msg, payload.Error)
+	}
+	if payload.AccessToken == "" {
+		return nil, fmt.Errorf("token response from %s has no access_token", o.TokenURL)
+	}
+
+	token := &OAuth2Token{AccessToken: payload.AccessToken, TokenType: payload.TokenType}
+	if seconds, err := payload.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		token.Expiry = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return token, nil
+}
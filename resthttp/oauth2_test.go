@@ -0,0 +1,60 @@
+package resthttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		token      string
+		wantReason string
+	}{
+		{"token", http.StatusOK, `{"access_token":"abc","token_type":"bearer","expires_in":60}`, ""},
+		{"rejected", http.StatusUnauthorized, `{"error":"invalid_client"}`, "Unauthorized"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The token endpoint is served over TLS with a certificate only the
+			// owning client, which skips verification, accepts.
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/token" {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.token))
+					return
+				}
+				if req.Header.Get("Authorization") != "Bearer abc" {
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer srv.Close()
+
+			r := NewRestHttp(srv.URL, WithAuthProvider(&OAuth2ClientCredentials{
+				TokenURL: srv.URL + "/token",
+				ClientID: "client",
+				Cache:    NewTokenCache(),
+			}))
+			r.VerifySSL = false
+
+			_, err := r.GetRequest("items", "1", nil, "", false)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var restErr *RestHttpError
+			if !errors.As(err, &restErr) {
+				t.Fatalf("got %v, want a RestHttpError", err)
+			}
+			if restErr.HttpStatus != tt.status || restErr.HttpReason != tt.wantReason {
+				t.Errorf("got %d %q, want %d %q", restErr.HttpStatus, restErr.HttpReason, tt.status, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package resthttp
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pacer spaces out consecutive requests of a loop, such as fetching every
+// page of a collection, so it stays polite towards small backends. It waits
+// Delay plus up to Jitter between requests and slows down adaptively when the
+// server gets slow or answers 429/503, recovering gradually once it is
+// healthy again.
+//
+//	pacer := &resthttp.Pacer{Delay: 200 * time.Millisecond, SlowThreshold: 2 * time.Second}
+//	for more {
+//		if err := pacer.Wait(ctx); err != nil {
+//			return err
+//		}
+//		start := time.Now()
+//		resp, err := client.NewRequest().Container("items").Query("page", page).Get(ctx)
+//		...
+//		pacer.Observe(time.Since(start), resp.StatusCode, resp.Header)
+//	}
+type Pacer struct {
+	// Delay is the minimum pause between two requests.
+	Delay time.Duration
+	// Jitter adds a random pause of up to this duration.
+	Jitter time.Duration
+	// SlowThreshold is the latency above which the pacer backs off. Zero
+	// disables latency based slow-down.
+	SlowThreshold time.Duration
+	// MaxDelay caps the adaptive delay. Defaults to one minute.
+	MaxDelay time.Duration
+	// Factor multiplies the delay on every slow-down and divides it on every
+	// healthy response. Defaults to 2.
+	Factor float64
+
+	mu      sync.Mutex
+	current time.Duration
+	started bool
+}
+
+// Wait blocks until the next request may be sent. The first call returns
+// immediately.
+func (p *Pacer) Wait(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.started {
+		p.started = true
+		p.mu.Unlock()
+		return ctx.Err()
+	}
+	delay := p.delayLocked()
+	p.mu.Unlock()
+
+	if p.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(p.Jitter)))
+	}
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Observe feeds the outcome of a request back into the pacer.
+func (p *Pacer) Observe(latency time.Duration, status int, header http.Header) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := p.delayLocked()
+	switch {
+	case status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable:
+		next := p.grow(current)
+		if retryAfter, ok := parseRetryAfter(header.Get("Retry-After"), time.Now()); ok && retryAfter > next {
+			next = retryAfter
+		}
+		p.current = p.clamp(next)
+	case p.SlowThreshold > 0 && latency > p.SlowThreshold:
+		p.current = p.clamp(p.grow(current))
+	default:
+		next := time.Duration(float64(current) / p.factor())
+		if next < p.Delay {
+			next = p.Delay
+		}
+		p.current = next
+	}
+}
+
+// CurrentDelay reports the pause the pacer will apply next, without jitter.
+func (p *Pacer) CurrentDelay() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.delayLocked()
+}
+
+func (p *Pacer) delayLocked() time.Duration {
+	if p.current < p.Delay {
+		return p.Delay
+	}
+	return p.current
+}
+
+func (p *Pacer) factor() float64 {
+	if p.Factor <= 1 {
+		return 2
+	}
+	return p.Factor
+}
+
+func (p *Pacer) grow(current time.Duration) time.Duration {
+	if current <= 0 {
+		current = 100 * time.Millisecond
+	}
+	return time.Duration(float64(current) * p.factor())
+}
+
+func (p *Pacer) clamp(delay time.Duration) time.Duration {
+	max := p.MaxDelay
+	if max <= 0 {
+		max = time.Minute
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+// parseRetryAfter understands both forms of Retry-After: delay seconds and
+// an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
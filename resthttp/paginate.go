@@ -0,0 +1,458 @@
+package resthttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PageStrategy decides where the page following resp lives. Next returns ""
+// when resp was the last page.
+//
+// PageStrategy is the extension point for APIs whose pagination scheme is
+// not covered by LinkHeader, Cursor, PageToken, PageNumber or Offset:
+// implement Next to compute the URL of the following page from the current
+// URL and the response, and pass the strategy to Paginate or
+// WithPageStrategy. Next must not modify current.
+type PageStrategy interface {
+	Next(current *url.URL, resp *Response) (string, error)
+}
+
+// firstPager is implemented by strategies that add query parameters, such
+// as the page size, to the first request too.
+type firstPager interface {
+	first(u *url.URL)
+}
+
+// WithPageStrategy sets the strategy used by Paginate calls that pass a nil
+// strategy. Without it they follow Link headers.
+func WithPageStrategy(strategy PageStrategy) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.pageStrategy = strategy
+	}
+}
+
+// LinkHeader follows RFC 8288 Link headers with rel="next", as sent by
+// GitHub and many other APIs.
+type LinkHeader struct{}
+
+func (LinkHeader) Next(current *url.URL, resp *Response) (string, error) {
+	next, ok := ParseLinkHeader(resp.Header.Values("Link"))["next"]
+	if !ok {
+		return "", nil
+	}
+	u, err := current.Parse(next)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// Cursor reads the next cursor from a field of the JSON body, such as
+// "meta.next_cursor", and sends it in the Param query parameter.
+type Cursor struct {
+	Field string
+	Param string
+}
+
+func (c Cursor) Next(current *url.URL, resp *Response) (string, error) {
+	value, err := jsonPath(resp.Body, c.Field)
+	if err != nil || value == nil {
+		return "", err
+	}
+	cursor := fmt.Sprint(value)
+	if cursor == "" {
+		return "", nil
+	}
+	return withQueryParam(current, c.Param, cursor), nil
+}
+
+// PageToken reads an opaque token from a field of the JSON body and sends it
+// back in a query parameter, in the style of Google APIs. Field defaults to
+// "nextPageToken" and Param to "pageToken".
+type PageToken struct {
+	Field string
+	Param string
+}
+
+func (t PageToken) Next(current *url.URL, resp *Response) (string, error) {
+	cursor := Cursor{Field: t.Field, Param: t.Param}
+	if cursor.Field == "" {
+		cursor.Field = "nextPageToken"
+	}
+	if cursor.Param == "" {
+		cursor.Param = "pageToken"
+	}
+	return cursor.Next(current, resp)
+}
+
+// FirstOf combines strategies for APIs that paginate some endpoints through
+// headers and others through the body: the first strategy finding a next
+// page wins.
+func FirstOf(strategies ...PageStrategy) PageStrategy {
+	return firstOf(strategies)
+}
+
+type firstOf []PageStrategy
+
+func (f firstOf) first(u *url.URL) {
+	for _, strategy := range f {
+		if fp, ok := strategy.(firstPager); ok {
+			fp.first(u)
+		}
+	}
+}
+
+func (f firstOf) Next(current *url.URL, resp *Response) (string, error) {
+	for _, strategy := range f {
+		next, err := strategy.Next(current, resp)
+		if err != nil || next != "" {
+			return next, err
+		}
+	}
+	return "", nil
+}
+
+// PageNumber increments the Param query parameter until a page holds fewer
+// than Size items. ItemsField locates the item array in the body; empty
+// means the body itself is the array. SizeParam and Size, when set, are sent
+// with every request, the first one included.
+type PageNumber struct {
+	Param string
+	// Start is the number of the first page, 1 when zero. APIs counting
+	// pages from 0 set ZeroBased instead.
+	Start      int
+	ZeroBased  bool
+	SizeParam  string
+	Size       int
+	ItemsField string
+}
+
+func (p PageNumber) start() int {
+	if p.Start == 0 && !p.ZeroBased {
+		return 1
+	}
+	return p.Start
+}
+
+func (p PageNumber) first(u *url.URL) {
+	query := u.Query()
+	if query.Get(p.Param) == "" {
+		query.Set(p.Param, strconv.Itoa(p.start()))
+	}
+	if p.SizeParam != "" && p.Size > 0 {
+		query.Set(p.SizeParam, strconv.Itoa(p.Size))
+	}
+	u.RawQuery = query.Encode()
+}
+
+func (p PageNumber) Next(current *url.URL, resp *Response) (string, error) {
+	count, err := countItems(resp.Body, p.ItemsField)
+	if err != nil {
+		return "", err
+	}
+	if count == 0 || (p.Size > 0 && count < p.Size) {
+		return "", nil
+	}
+
+	page := p.start()
+	if value := current.Query().Get(p.Param); value != "" {
+		if page, err = strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("invalid page number %q", value)
+		}
+	}
+
+	next, _ := url.Parse(withQueryParam(current, p.Param, strconv.Itoa(page+1)))
+	if p.SizeParam != "" && p.Size > 0 {
+		return withQueryParam(next, p.SizeParam, strconv.Itoa(p.Size)), nil
+	}
+	return next.String(), nil
+}
+
+// Offset advances the Param query parameter by the number of items received
+// until a page holds fewer than Limit items. LimitParam and Limit, when set,
+// are sent with every request, the first one included.
+type Offset struct {
+	Param      string
+	LimitParam string
+	Limit      int
+	ItemsField string
+}
+
+func (o Offset) first(u *url.URL) {
+	if o.LimitParam != "" && o.Limit > 0 {
+		query := u.Query()
+		query.Set(o.LimitParam, strconv.Itoa(o.Limit))
+		u.RawQuery = query.Encode()
+	}
+}
+
+func (o Offset) Next(current *url.URL, resp *Response) (string, error) {
+	count, err := countItems(resp.Body, o.ItemsField)
+	if err != nil {
+		return "", err
+	}
+	if count == 0 || (o.Limit > 0 && count < o.Limit) {
+		return "", nil
+	}
+
+	offset := 0
+	if value := current.Query().Get(o.Param); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("invalid offset %q", value)
+		}
+	}
+
+	next, _ := url.Parse(withQueryParam(current, o.Param, strconv.Itoa(offset+count)))
+	if o.LimitParam != "" && o.Limit > 0 {
+		return withQueryParam(next, o.LimitParam, strconv.Itoa(o.Limit)), nil
+	}
+	return next.String(), nil
+}
+
+// Paginator walks the pages of a collection:
+//
+//	pages := client.Paginate(ctx, "items", nil, resthttp.LinkHeader{})
+//	for pages.Next() {
+//		handle(pages.Page())
+//	}
+//	if err := pages.Err(); err != nil {
+//		...
+//	}
+type Paginator struct {
+	client   *RestHttp
+	ctx      context.Context
+	strategy PageStrategy
+	opts     []RequestOption
+	pacer    *Pacer
+	next     string
+	page     *Response
+	err      error
+}
+
+// maxThrottledAttempts bounds how often a paginator with a Pacer retries a
+// page answered with 429 or 503.
+const maxThrottledAttempts = 5
+
+// Paginate walks the collection at container. A nil strategy selects the
+// client's WithPageStrategy, or LinkHeader.
+func (r *RestHttp) Paginate(ctx context.Context, container string, query url.Values, strategy PageStrategy, opts ...RequestOption) *Paginator {
+	if strategy == nil {
+		strategy = r.pageStrategy
+	}
+	if strategy == nil {
+		strategy = LinkHeader{}
+	}
+	next := r.MakeURL(container, "", query)
+	if fp, ok := strategy.(firstPager); ok {
+		if u, err := url.Parse(next); err == nil {
+			fp.first(u)
+			next = u.String()
+		}
+	}
+	return &Paginator{
+		client:   r,
+		ctx:      ctx,
+		strategy: strategy,
+		opts:     opts,
+		next:     next,
+	}
+}
+
+// Pace spaces out page requests with pacer, which also retries throttled
+// pages after slowing down.
+func (p *Paginator) Pace(pacer *Pacer) *Paginator {
+	p.pacer = pacer
+	return p
+}
+
+// Next fetches the following page and reports whether there was one.
+func (p *Paginator) Next() bool {
+	if p.err != nil || p.next == "" {
+		return false
+	}
+
+	current, err := url.Parse(p.next)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	resp, err := p.fetch()
+	if err != nil {
+		p.err = err
+		return false
+	}
+	if resp.failed() {
+		p.err = p.client.statusError(resp.StatusCode, resp.Status, resp.Header, resp.Body)
+		return false
+	}
+
+	next, err := p.strategy.Next(current, resp)
+	if err != nil {
+		p.err = err
+		return false
+	}
+	if next == p.next {
+		next = ""
+	}
+
+	p.page = resp
+	p.next = next
+	return true
+}
+
+func (p *Paginator) fetch() (*Response, error) {
+	for attempt := 1; ; attempt++ {
+		if p.pacer != nil {
+			if err := p.pacer.Wait(p.ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		start := time.Now()
+		resp, err := p.client.execute(p.ctx, "GET", p.next, nil, p.opts)
+		if err != nil {
+			return nil, err
+		}
+		if p.pacer == nil {
+			return resp, nil
+		}
+
+		p.pacer.Observe(time.Since(start), resp.StatusCode, resp.Header)
+		throttled := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+		if !throttled || attempt >= maxThrottledAttempts {
+			return resp, nil
+		}
+	}
+}
+
+// Page returns the page fetched by the last successful call to Next.
+func (p *Paginator) Page() *Response {
+	return p.page
+}
+
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+// Items walks all remaining pages and calls fn for every element of the item
+// array found at field (empty for a top level array).
+func (p *Paginator) Items(field string, fn func(item json.RawMessage) error) error {
+	for p.Next() {
+		items, err := rawItems(p.page.Body, field)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+	}
+	return p.Err()
+}
+
+// ParseLinkHeader maps the rel values of RFC 8288 Link headers to their
+// target URLs.
+func ParseLinkHeader(values []string) map[string]string {
+	links := make(map[string]string)
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = target[1 : len(target)-1]
+			for _, param := range parts[1:] {
+				name, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(val), `"`)) {
+					links[strings.ToLower(rel)] = target
+				}
+			}
+		}
+	}
+	return links
+}
+
+func withQueryParam(u *url.URL, key string, value string) string {
+	next := *u
+	query := next.Query()
+	query.Set(key, value)
+	next.RawQuery = query.Encode()
+	return next.String()
+}
+
+// jsonPath extracts the value at a dotted path like "meta.next" or
+// "data.0.id" from a JSON document. Missing fields yield nil.
+func jsonPath(body []byte, path string) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return doc, nil
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			doc = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, nil
+			}
+			doc = node[i]
+		default:
+			return nil, nil
+		}
+	}
+	return doc, nil
+}
+
+func rawItems(body []byte, field string) ([]json.RawMessage, error) {
+	if field == "" {
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+
+	value, err := jsonPath(body, field)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("field %s is not an array", field)
+	}
+
+	items := make([]json.RawMessage, 0, len(list))
+	for _, item := range list {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, data)
+	}
+	return items, nil
+}
+
+func countItems(body []byte, field string) (int, error) {
+	items, err := rawItems(body, field)
+	return len(items), err
+}
@@ -0,0 +1,60 @@
+package resthttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestPaginateFirstRequest(t *testing.T) {
+	// The server pages 7 items, 2 per page unless a size is requested.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		size := 2
+		if n, err := strconv.Atoi(query.Get("size")); err == nil {
+			size = n
+		}
+		start := 0
+		if n, err := strconv.Atoi(query.Get("page")); err == nil {
+			start = n * size
+		}
+		if n, err := strconv.Atoi(query.Get("offset")); err == nil {
+			start = n
+		}
+		items := []int{}
+		for i := start; i < start+size && i < 7; i++ {
+			items = append(items, i)
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		strategy PageStrategy
+		want     int
+	}{
+		{"page number", PageNumber{Param: "page", ZeroBased: true, SizeParam: "size", Size: 3}, 7},
+		{"page number from one", PageNumber{Param: "page", SizeParam: "size", Size: 3}, 4},
+		{"offset", Offset{Param: "offset", LimitParam: "size", Limit: 3}, 7},
+		{"first of", FirstOf(LinkHeader{}, Offset{Param: "offset", LimitParam: "size", Limit: 3}), 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := 0
+			err := NewRestHttp(srv.URL).Paginate(context.Background(), "items", nil, tt.strategy).Items("", func(json.RawMessage) error {
+				count++
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.want {
+				t.Errorf("got %d items, want %d", count, tt.want)
+			}
+		})
+	}
+}
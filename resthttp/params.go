@@ -0,0 +1,67 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// ParamsSerializer encodes the params of PostRequest and PutRequest. It
+// returns the request body with its content type, and optionally query values
+// to be appended to the URL instead.
+type ParamsSerializer func(params url.Values) (body []byte, contentType string, query url.Values, err error)
+
+// FormParams sends params as an application/x-www-form-urlencoded body. It is
+// the default unless the request sets a Content-Type a codec is registered
+// for.
+func FormParams(params url.Values) ([]byte, string, url.Values, error) {
+	return []byte(params.Encode()), "application/x-www-form-urlencoded", nil, nil
+}
+
+// JSONParams sends params as a flat JSON object. Keys with a single value
+// become strings, keys with several values become arrays of strings.
+func JSONParams(params url.Values) ([]byte, string, url.Values, error) {
+	data, err := json.Marshal(paramsObject(params))
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return data, "application/json", nil, nil
+}
+
+// CodecParams sends params encoded with codec, shaped as by JSONParams.
+func CodecParams(codec Codec) ParamsSerializer {
+	return func(params url.Values) ([]byte, string, url.Values, error) {
+		data, err := codec.Marshal(paramsObject(params))
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return data, codec.ContentType(), nil, nil
+	}
+}
+
+func paramsObject(params url.Values) map[string]interface{} {
+	object := make(map[string]interface{}, len(params))
+	for key, values := range params {
+		if len(values) == 1 {
+			object[key] = values[0]
+		} else {
+			object[key] = values
+		}
+	}
+	return object
+}
+
+// QueryParams sends params in the URL query string with an empty body.
+func QueryParams(params url.Values) ([]byte, string, url.Values, error) {
+	if len(params) == 0 {
+		return nil, "", nil, nil
+	}
+	return nil, "", params, nil
+}
+
+// WithParamsSerializer selects how PostRequest and PutRequest encode their
+// params for this request.
+func WithParamsSerializer(serializer ParamsSerializer) RequestOption {
+	return func(ro *requestOptions) {
+		ro.paramsSerializer = serializer
+	}
+}
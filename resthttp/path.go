@@ -0,0 +1,84 @@
+package resthttp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PathParams holds the values substituted into {name} placeholders of a path
+// template.
+type PathParams map[string]string
+
+// ExpandPath substitutes every {name} placeholder in template with the
+// url.PathEscape'd value from params. A placeholder without a value is an
+// error, so a typo can never silently produce a wrong URL.
+func ExpandPath(template string, params PathParams) (string, error) {
+	var sb strings.Builder
+	rest := template
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			if strings.IndexByte(rest, '}') >= 0 {
+				return "", fmt.Errorf("unbalanced '}' in path template %q", template)
+			}
+			sb.WriteString(rest)
+			break
+		}
+
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder in path template %q", template)
+		}
+		end += open
+
+		name := rest[open+1 : end]
+		if name == "" || strings.ContainsAny(name, "{/") {
+			return "", fmt.Errorf("invalid placeholder %q in path template %q", rest[open:end+1], template)
+		}
+
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("missing value for path parameter %q in %q", name, template)
+		}
+		if value == "" {
+			return "", fmt.Errorf("empty value for path parameter %q in %q", name, template)
+		}
+
+		sb.WriteString(rest[:open])
+		sb.WriteString(url.PathEscape(value))
+		rest = rest[end+1:]
+	}
+
+	return sb.String(), nil
+}
+
+// URLFor expands template and joins it to BaseURL.
+func (r *RestHttp) URLFor(template string, params PathParams) (string, error) {
+	path, err := ExpandPath(template, params)
+	if err != nil {
+		return "", err
+	}
+	return r.baseURL() + "/" + strings.TrimLeft(path, "/"), nil
+}
+
+func (r *RestHttp) Get(ctx context.Context, template string, params PathParams, opts ...RequestOption) (*Response, error) {
+	return r.NewRequest().Path(template, params).Option(opts...).Get(ctx)
+}
+
+func (r *RestHttp) Delete(ctx context.Context, template string, params PathParams, opts ...RequestOption) (*Response, error) {
+	return r.NewRequest().Path(template, params).Option(opts...).Delete(ctx)
+}
+
+// Path addresses the request with a path template instead of a container
+// and resource.
+func (b *RequestBuilder) Path(template string, params PathParams) *RequestBuilder {
+	path, err := ExpandPath(template, params)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.path = path
+	return b
+}
@@ -0,0 +1,147 @@
+package resthttp
+
+import (
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// hostPool spreads requests over several equivalent base URLs. Requests are
+// round-robined unless they carry an affinity key or the pool has seen the
+// configured sticky session cookie, in which case they are pinned to a node.
+type hostPool struct {
+	mu         sync.Mutex
+	hosts      []string
+	next       int
+	cookieName string
+	cookie     *http.Cookie
+	cookieHost string
+}
+
+// WithHosts load balances requests across baseURLs, which must all serve the
+// same API. BaseURL defaults to the first host when it is empty.
+func WithHosts(baseURLs ...string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		hosts := make([]string, 0, len(baseURLs))
+		for _, host := range baseURLs {
+			hosts = append(hosts, strings.TrimRight(host, "/"))
+		}
+		if len(hosts) == 0 {
+			return
+		}
+		r.pool = r.pool.reconfigure()
+		r.pool.hosts = hosts
+		if r.BaseURL == "" {
+			r.BaseURL = hosts[0]
+		}
+	}
+}
+
+// WithStickyCookie pins follow-up requests to the host that issued the named
+// session cookie and sends the cookie back with them. It has no effect
+// without WithHosts.
+func WithStickyCookie(name string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.pool = r.pool.reconfigure()
+		r.pool.cookieName = name
+	}
+}
+
+// reconfigure returns a fresh pool with the configuration of p, which may be
+// nil, so that options applied to a clone leave the original's pool alone.
+func (p *hostPool) reconfigure() *hostPool {
+	if p == nil {
+		return &hostPool{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &hostPool{hosts: p.hosts, cookieName: p.cookieName}
+}
+
+// WithAffinityKey routes every request carrying the same key to the same host
+// of the pool configured with WithHosts.
+func WithAffinityKey(key string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.affinityKey = key
+	}
+}
+
+func (p *hostPool) pick(affinityKey string) (string, *http.Cookie) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if affinityKey != "" {
+		h := fnv.New32a()
+		h.Write([]byte(affinityKey))
+		return p.hosts[int(h.Sum32()%uint32(len(p.hosts)))], nil
+	}
+
+	if p.cookie != nil {
+		return p.cookieHost, p.cookie
+	}
+
+	host := p.hosts[p.next%len(p.hosts)]
+	p.next++
+	return host, nil
+}
+
+func (p *hostPool) observe(resp *http.Response) {
+	if p.cookieName == "" || resp.Request == nil {
+		return
+	}
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name != p.cookieName {
+			continue
+		}
+
+		host := p.hostFor(resp.Request.URL)
+		if host == "" {
+			return
+		}
+
+		p.mu.Lock()
+		if cookie.MaxAge < 0 || cookie.Value == "" {
+			p.cookie = nil
+			p.cookieHost = ""
+		} else {
+			p.cookie = &http.Cookie{Name: cookie.Name, Value: cookie.Value}
+			p.cookieHost = host
+		}
+		p.mu.Unlock()
+		return
+	}
+}
+
+func (p *hostPool) hostFor(u *url.URL) string {
+	urlStr := u.String()
+	for _, host := range p.hosts {
+		if strings.HasPrefix(urlStr, host) {
+			return host
+		}
+	}
+	return ""
+}
+
+// route points req at the pool host selected for it.
+func (p *hostPool) route(req *http.Request, baseURL string, ro *requestOptions) {
+	if len(p.hosts) == 0 {
+		return
+	}
+
+	host, cookie := p.pick(ro.affinityKey)
+
+	urlStr := req.URL.String()
+	if host != baseURL && strings.HasPrefix(urlStr, baseURL) {
+		if u, err := url.Parse(host + urlStr[len(baseURL):]); err == nil {
+			req.URL = u
+			req.Host = u.Host
+		}
+	}
+
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+}
@@ -0,0 +1,247 @@
+package resthttp
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DownloadInfo describes a downloaded file while it sits in quarantine.
+type DownloadInfo struct {
+	// Path is the quarantined file; SavePath is where it will be moved.
+	Path     string
+	SavePath string
+	Size     int64
+	Header   http.Header
+}
+
+// DownloadCheck inspects a quarantined download. Returning an error discards
+// the file.
+type DownloadCheck func(info DownloadInfo) error
+
+// DownloadRejectedError is returned when a DownloadCheck refused a file.
+type DownloadRejectedError struct {
+	SavePath string
+	Err      error
+}
+
+func (e *DownloadRejectedError) Error() string {
+	return fmt.Sprintf("download to %s rejected: %s", e.SavePath, e.Err)
+}
+
+func (e *DownloadRejectedError) Unwrap() error {
+	return e.Err
+}
+
+// WithDownloadQuarantine makes DownloadFile write into dir first; files are
+// only moved to their save path after all download checks passed. Without it
+// the quarantine file lives next to the save path.
+func WithDownloadQuarantine(dir string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.quarantineDir = dir
+	}
+}
+
+// WithDownloadChecks adds checks run on every file fetched by DownloadFile.
+func WithDownloadChecks(checks ...DownloadCheck) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.downloadChecks = append(r.downloadChecks, checks...)
+	}
+}
+
+// WithExpectedSHA256 verifies the downloaded file against a hex encoded
+// SHA-256 digest.
+func WithExpectedSHA256(digest string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.downloadChecks = append(ro.downloadChecks, SHA256Check(digest))
+	}
+}
+
+// SHA256Check compares the file with a hex encoded SHA-256 digest.
+func SHA256Check(digest string) DownloadCheck {
+	return func(info DownloadInfo) error {
+		sum, err := fileDigest(info.Path, sha256.New())
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(hex.EncodeToString(sum), digest) {
+			return fmt.Errorf("sha256 mismatch: got %x, want %s", sum, digest)
+		}
+		return nil
+	}
+}
+
+// HeaderChecksumCheck verifies the file against the checksum announced by the
+// server in Digest (sha-256 or md5), Content-MD5 or X-Checksum-Sha256, if
+// any is present.
+func HeaderChecksumCheck() DownloadCheck {
+	return func(info DownloadInfo) error {
+		for _, item := range splitHeaderList(info.Header.Values("Digest")) {
+			algorithm, value, ok := strings.Cut(item, "=")
+			if !ok {
+				continue
+			}
+			switch strings.ToLower(algorithm) {
+			case "sha-256":
+				return compareBase64Digest(info.Path, sha256.New(), value)
+			case "md5":
+				return compareBase64Digest(info.Path, md5.New(), value)
+			}
+		}
+		if value := info.Header.Get("Content-MD5"); value != "" {
+			return compareBase64Digest(info.Path, md5.New(), value)
+		}
+		if value := info.Header.Get("X-Checksum-Sha256"); value != "" {
+			return SHA256Check(value)(info)
+		}
+		return nil
+	}
+}
+
+// JSONCheck rejects downloads that are not well-formed JSON.
+func JSONCheck() DownloadCheck {
+	return func(info DownloadInfo) error {
+		file, err := os.Open(info.Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		decoder := json.NewDecoder(file)
+		for {
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("invalid JSON: %s", err)
+			}
+		}
+	}
+}
+
+func compareBase64Digest(path string, h hash.Hash, expected string) error {
+	want, err := base64.StdEncoding.DecodeString(expected)
+	if err != nil {
+		return fmt.Errorf("malformed checksum %q", expected)
+	}
+	got, err := fileDigest(path, h)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", base64.StdEncoding.EncodeToString(got), expected)
+	}
+	return nil
+}
+
+func fileDigest(path string, h hash.Hash) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// saveDownload writes body into quarantine, runs the download checks and
+// moves the file to savePath once they all passed.
+func (r *RestHttp) saveDownload(body io.Reader, savePath string, header http.Header, ro *requestOptions) (int64, error) {
+	dir := r.quarantineDir
+	if dir == "" {
+		dir = filepath.Dir(savePath)
+	}
+	tmp, err := createPartFile(dir, filepath.Base(savePath))
+	if err != nil {
+		return 0, fmt.Errorf("could not create file: %s", err)
+	}
+	tmpPath := tmp.Name()
+	keep := false
+	defer func() {
+		if !keep {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	size, err := io.Copy(tmp, body)
+	closeErr := tmp.Close()
+	if err != nil {
+		return size, fmt.Errorf("could not download file: %s", err)
+	}
+	if closeErr != nil {
+		return size, fmt.Errorf("could not download file: %s", closeErr)
+	}
+
+	info := DownloadInfo{Path: tmpPath, SavePath: savePath, Size: size, Header: header}
+	checks := append(append([]DownloadCheck(nil), r.downloadChecks...), ro.downloadChecks...)
+	for _, check := range checks {
+		if err := check(info); err != nil {
+			return size, &DownloadRejectedError{SavePath: savePath, Err: err}
+		}
+	}
+
+	if err := moveFile(tmpPath, savePath); err != nil {
+		return size, fmt.Errorf("could not move download into place: %s", err)
+	}
+	keep = true
+	return size, nil
+}
+
+// createPartFile creates a new hidden file in dir to download name into.
+// Unlike ioutil.TempFile it uses mode 0666 before the umask, so the saved
+// file gets the same permissions as one made by os.Create.
+func createPartFile(dir string, name string) (*os.File, error) {
+	for try := 0; ; try++ {
+		suffix := make([]byte, 6)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, "."+name+"."+hex.EncodeToString(suffix)+".part")
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && try < 100 {
+			continue
+		}
+		return file, err
+	}
+}
+
+// moveFile renames src to dst, copying when they live on different file
+// systems.
+func moveFile(src string, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,37 @@
+package resthttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadFileMode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	created, err := os.Create(filepath.Join(dir, "created"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created.Close()
+	want, err := os.Stat(created.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	savePath := filepath.Join(dir, "downloaded")
+	if err := NewRestHttp(srv.URL).DownloadFile("files", "a", savePath, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.Stat(savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Mode() != want.Mode() {
+		t.Errorf("downloaded file has mode %s, want %s like os.Create", got.Mode(), want.Mode())
+	}
+}
@@ -0,0 +1,174 @@
+package resthttp
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeQuery turns a struct into url.Values for GetRequest and
+// DeleteRequest. Fields are named by their `query` tag and support these
+// options:
+//
+//	omitempty  skip zero values
+//	comma      send slices as one comma-joined value instead of repeated keys
+//	unix       send time.Time as Unix seconds
+//
+// time.Time fields use RFC 3339 unless a `layout` tag such as
+// `layout:"2006-01-02"` is present. Embedded structs are flattened.
+func EncodeQuery(v interface{}) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("EncodeQuery expects a struct, got %s", rv.Type())
+	}
+
+	values := make(url.Values)
+	if err := encodeQueryStruct(values, rv); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// WithQueryStruct adds the fields of v, encoded with EncodeQuery, to the
+// query of this request.
+func WithQueryStruct(v interface{}) RequestOption {
+	return func(ro *requestOptions) {
+		values, err := EncodeQuery(v)
+		if err != nil {
+			ro.err = err
+			return
+		}
+		for key, vals := range values {
+			for _, value := range vals {
+				WithQuery(key, value)(ro)
+			}
+		}
+	}
+}
+
+func encodeQueryStruct(values url.Values, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		tag := field.Tag.Get("query")
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				if err := encodeQueryStruct(values, fv); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, opts := parseQueryTag(tag, field.Name)
+		if opts["omitempty"] && fv.IsZero() {
+			continue
+		}
+
+		for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if (fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface) && fv.IsNil() {
+			continue
+		}
+
+		if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && fv.Type().Elem().Kind() != reflect.Uint8 {
+			items := make([]string, 0, fv.Len())
+			for j := 0; j < fv.Len(); j++ {
+				s, err := formatQueryValue(fv.Index(j), field, opts)
+				if err != nil {
+					return err
+				}
+				items = append(items, s)
+			}
+			if opts["comma"] {
+				if len(items) > 0 {
+					values.Add(name, strings.Join(items, ","))
+				}
+			} else {
+				for _, item := range items {
+					values.Add(name, item)
+				}
+			}
+			continue
+		}
+
+		s, err := formatQueryValue(fv, field, opts)
+		if err != nil {
+			return err
+		}
+		values.Add(name, s)
+	}
+	return nil
+}
+
+func parseQueryTag(tag string, fallback string) (string, map[string]bool) {
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = fallback
+	}
+	opts := make(map[string]bool, len(parts)-1)
+	for _, opt := range parts[1:] {
+		opts[opt] = true
+	}
+	return name, opts
+}
+
+func formatQueryValue(v reflect.Value, field reflect.StructField, opts map[string]bool) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if opts["unix"] {
+			return strconv.FormatInt(t.Unix(), 10), nil
+		}
+		layout := field.Tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return t.Format(layout), nil
+	}
+
+	s, ok, err := formatScalar(v)
+	if err != nil {
+		return "", fmt.Errorf("query field %s: %s", field.Name, err)
+	}
+	if !ok {
+		return "", fmt.Errorf("query field %s: unsupported type %s", field.Name, v.Type())
+	}
+	return s, nil
+}
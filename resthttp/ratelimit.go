@@ -0,0 +1,83 @@
+package resthttp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the client to perSecond requests on average, allowing
+// bursts of up to burst requests. Every attempt, including retries, takes a
+// token; requests wait for one until their context is done. The remaining
+// tokens are reported as Stats.LimiterTokens. Clones share the limit.
+func WithRateLimit(perSecond float64, burst int) func(*RestHttp) {
+	return func(r *RestHttp) {
+		if perSecond <= 0 {
+			r.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		r.limiter = &rateLimiter{rate: perSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+	}
+}
+
+// rateLimiter is a token bucket.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// refillLocked adds the tokens accrued since the last call.
+func (l *rateLimiter) refillLocked(now time.Time) {
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// wait takes a token, blocking until one is available. A nil limiter never
+// blocks.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	l.refillLocked(time.Now())
+	// Taking the token up front reserves it, so that concurrent waiters
+	// queue up behind each other instead of all waking at once.
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// available reports the tokens left, negative while requests are queued.
+func (l *rateLimiter) available() float64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refillLocked(time.Now())
+	return l.tokens
+}
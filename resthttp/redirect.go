@@ -0,0 +1,58 @@
+package resthttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// RedirectPolicy controls how the client follows redirects.
+type RedirectPolicy struct {
+	// MaxRedirects bounds the redirects followed per request; zero means 10.
+	MaxRedirects int
+	// NoFollow returns redirect responses instead of following them. Use
+	// WithAnyStatus or the Response based API to inspect them.
+	NoFollow bool
+}
+
+// ErrTooManyRedirects is returned, wrapped, when a request is redirected more
+// often than RedirectPolicy.MaxRedirects allows.
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+func WithRedirectPolicy(policy *RedirectPolicy) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.redirect = policy
+	}
+}
+
+// WithRedirectChain stores the URLs this request was redirected to, in the
+// order they were followed, into chain.
+func WithRedirectChain(chain *[]string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.redirectChain = chain
+	}
+}
+
+// checkRedirect is the http.Client CheckRedirect for a request made with ro.
+func (r *RestHttp) checkRedirect(ro *requestOptions) func(req *http.Request, via []*http.Request) error {
+	policy := r.redirect
+	return func(req *http.Request, via []*http.Request) error {
+		max := 10
+		if policy != nil {
+			if policy.NoFollow {
+				return http.ErrUseLastResponse
+			}
+			if policy.MaxRedirects > 0 {
+				max = policy.MaxRedirects
+			}
+		}
+		if len(via) > max {
+			return fmt.Errorf("stopped after %d redirects: %w", max, ErrTooManyRedirects)
+		}
+
+		if ro.redirectChain != nil {
+			*ro.redirectChain = append(*ro.redirectChain, req.URL.String())
+		}
+		return nil
+	}
+}
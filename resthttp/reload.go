@@ -0,0 +1,74 @@
+package resthttp
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// WatchSignals calls reload every time the process receives one of sigs
+// (SIGHUP when none are given) until ctx is done. reload typically reads the
+// configuration again and applies it with the Set methods.
+func (r *RestHttp) WatchSignals(ctx context.Context, reload func(*RestHttp), sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				reload(r)
+			}
+		}
+	}()
+}
+
+// WatchFile polls path every interval and calls reload with its contents
+// whenever the modification time or size changes, until ctx is done. reload
+// is called once right away with the current contents.
+func (r *RestHttp) WatchFile(ctx context.Context, path string, interval time.Duration, reload func(r *RestHttp, data []byte)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	reload(r, data)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		modTime, size := info.ModTime(), info.Size()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil || (info.ModTime().Equal(modTime) && info.Size() == size) {
+				continue
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			modTime, size = info.ModTime(), info.Size()
+			reload(r, data)
+		}
+	}()
+	return nil
+}
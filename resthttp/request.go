@@ -0,0 +1,169 @@
+package resthttp
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestOption customizes a single request without touching the client's
+// shared configuration.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	header http.Header
+	query  url.Values
+	auth   AuthProvider
+	noAuth bool
+
+	affinityKey      string
+	paramsSerializer ParamsSerializer
+	keepEncoded      bool
+	stream           bool
+	downloadChecks   []DownloadCheck
+	dump             *debugDump
+	expectedStatus   []int
+	noRetry          bool
+	anyStatus        bool
+	redirectChain    *[]string
+	uaProducts       []string
+	tenant           string
+	timeout          time.Duration
+	headerTimeout    time.Duration
+	maxResponseBytes int64
+
+	// err records a failure while applying an option; it is reported when
+	// the request is prepared.
+	err error
+}
+
+func newRequestOptions(opts []RequestOption) *requestOptions {
+	ro := &requestOptions{}
+	for _, opt := range opts {
+		opt(ro)
+	}
+	return ro
+}
+
+// WithRequestHeader sets a header for this request only, replacing any value
+// coming from BaseHeaders or the method arguments.
+func WithRequestHeader(key string, value string) RequestOption {
+	return func(ro *requestOptions) {
+		if ro.header == nil {
+			ro.header = make(http.Header)
+		}
+		ro.header.Add(key, value)
+	}
+}
+
+// setRequestHeader is WithRequestHeader dropping values added by earlier
+// options.
+func setRequestHeader(key string, value string) RequestOption {
+	return func(ro *requestOptions) {
+		if ro.header == nil {
+			ro.header = make(http.Header)
+		}
+		ro.header.Set(key, value)
+	}
+}
+
+// WithQuery adds a query parameter to this request.
+func WithQuery(key string, value string) RequestOption {
+	return func(ro *requestOptions) {
+		if ro.query == nil {
+			ro.query = make(url.Values)
+		}
+		ro.query.Add(key, value)
+	}
+}
+
+// WithBasicAuth sends the given credentials instead of the client's User and
+// Password for this request.
+func WithBasicAuth(user string, password string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.auth = BasicAuth{User: user, Password: password}
+	}
+}
+
+// WithExpectedStatus declares the statuses this call treats as success, for
+// example 409 where a conflict means the resource already exists. Any other
+// status, including unlisted 2xx ones, is returned as an error.
+func WithExpectedStatus(codes ...int) RequestOption {
+	return func(ro *requestOptions) {
+		ro.expectedStatus = append(ro.expectedStatus, codes...)
+	}
+}
+
+// WithAnyStatus returns the response of this call whatever its status,
+// instead of turning unsuccessful ones into errors.
+func WithAnyStatus() RequestOption {
+	return func(ro *requestOptions) {
+		ro.anyStatus = true
+	}
+}
+
+// acceptsStatus reports whether code counts as success, which without
+// WithExpectedStatus means 2xx and 304 Not Modified.
+func (ro *requestOptions) acceptsStatus(code int) bool {
+	if ro.expectedStatus == nil {
+		return code < 300 || code == http.StatusNotModified
+	}
+	for _, expected := range ro.expectedStatus {
+		if code == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// WithNoAuth sends this request anonymously: no client credentials are
+// applied and any Authorization or Cookie header is stripped, including ones
+// coming from BaseHeaders. Use it for public metadata and token endpoints.
+func WithNoAuth() RequestOption {
+	return func(ro *requestOptions) {
+		ro.noAuth = true
+	}
+}
+
+// WithBasicAuthOverride is the original name of WithBasicAuth.
+//
+// Deprecated: use WithBasicAuth.
+func WithBasicAuthOverride(user string, password string) RequestOption {
+	return WithBasicAuth(user, password)
+}
+
+// WithToken authenticates this request with a bearer token instead of the
+// client's credentials.
+func WithToken(token string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.auth = TokenAuth{Token: token}
+	}
+}
+
+// WithAuth authenticates this request with provider instead of the
+// client's credentials, so one client can act for different principals:
+//
+//	client.GetRequest("users", "me", nil, "", false, resthttp.WithAuth(resthttp.TokenAuth{Token: userToken}))
+func WithAuth(provider AuthProvider) RequestOption {
+	return func(ro *requestOptions) {
+		ro.auth = provider
+	}
+}
+
+// IfNoneMatch makes the request conditional on the resource no longer
+// matching etag. An unchanged resource is answered with 304, reported as
+// Response.NotModified.
+func IfNoneMatch(etag string) RequestOption {
+	if etag != "*" && !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+		etag = strconv.Quote(etag)
+	}
+	return WithRequestHeader("If-None-Match", etag)
+}
+
+// IfModifiedSince makes the request conditional on the resource having
+// changed after t.
+func IfModifiedSince(t time.Time) RequestOption {
+	return WithRequestHeader("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+}
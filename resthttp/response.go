@@ -0,0 +1,109 @@
+package resthttp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Response struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+	// NotModified is set when a conditional request was answered with 304;
+	// Body is empty in that case.
+	NotModified bool
+
+	codecs   []Codec
+	expected []int
+}
+
+func newResponse(resp *http.Response, body []byte) *Response {
+	return &Response{
+		StatusCode:  resp.StatusCode,
+		Status:      resp.Status,
+		Header:      resp.Header,
+		Body:        body,
+		NotModified: resp.StatusCode == http.StatusNotModified,
+	}
+}
+
+func (resp *Response) String() string {
+	return string(resp.Body)
+}
+
+// ETag returns the entity tag of the response, ready to be passed to
+// IfNoneMatch.
+func (resp *Response) ETag() string {
+	return resp.Header.Get("ETag")
+}
+
+// LastModified returns the parsed Last-Modified header, or the zero time
+// when it is missing or malformed.
+func (resp *Response) LastModified() time.Time {
+	t, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func (resp *Response) IsSuccess() bool {
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+func (resp *Response) IsRedirect() bool {
+	return resp.StatusCode >= 300 && resp.StatusCode < 400
+}
+
+func (resp *Response) IsClientError() bool {
+	return resp.StatusCode >= 400 && resp.StatusCode < 500
+}
+
+func (resp *Response) IsServerError() bool {
+	return resp.StatusCode >= 500 && resp.StatusCode < 600
+}
+
+// failed reports whether the response is an error for the request that
+// produced it, honouring WithExpectedStatus.
+func (resp *Response) failed() bool {
+	ro := requestOptions{expectedStatus: resp.expected}
+	return !ro.acceptsStatus(resp.StatusCode)
+}
+
+// MustStatus returns an *UnexpectedStatusError unless the response has one of
+// the given status codes. Without codes any 2xx status is accepted.
+func (resp *Response) MustStatus(codes ...int) error {
+	if len(codes) == 0 {
+		if resp.IsSuccess() {
+			return nil
+		}
+	}
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	return &UnexpectedStatusError{Response: resp, Expected: codes}
+}
+
+// UnexpectedStatusError is returned by MustStatus.
+type UnexpectedStatusError struct {
+	Response *Response
+	// Expected is empty when any 2xx status was acceptable.
+	Expected []int
+}
+
+func (e *UnexpectedStatusError) Error() string {
+	if len(e.Expected) == 0 {
+		return fmt.Sprintf("unexpected status %s, want 2xx", e.Response.Status)
+	}
+	expected := make([]string, len(e.Expected))
+	for i, code := range e.Expected {
+		expected[i] = strconv.Itoa(code)
+	}
+	return fmt.Sprintf("unexpected status %s, want %s", e.Response.Status, strings.Join(expected, " or "))
+}
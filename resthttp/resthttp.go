@@ -2,8 +2,8 @@ package resthttp
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
-	"encoding/base64"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,7 +12,11 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 )
 
@@ -21,6 +25,13 @@ type RestHttpError struct {
 	HttpReason string
 	Msg        string
 	Code       string
+	// ServerMsg is the message as sent by the server when Msg has been
+	// localized by a MessageCatalog.
+	ServerMsg string
+	// Header and Body are those of the error response; Body holds at most
+	// the first 64KB.
+	Header http.Header
+	Body   []byte
 }
 
 func NewRestHttpError(httpStatus int, httpReason string, msg string, code string) *RestHttpError {
@@ -68,6 +79,12 @@ func (e *ConnectionError) Code() int {
 	return e.ErrorCode
 }
 
+// RestHttp is safe for concurrent use by multiple goroutines once it is
+// configured. Requests never modify the client: headers, query parameters
+// and credentials are merged into each request. The exported fields must not
+// be written while requests are in flight; use the Set methods, such as
+// SetBaseHeader and SetCredentials, to change the configuration of a client
+// in use.
 type RestHttp struct {
 	BaseURL     string
 	BaseHeaders http.Header
@@ -76,6 +93,85 @@ type RestHttp struct {
 	VerifySSL   bool
 	DebugPrint  bool
 	Timeout     time.Duration
+
+	// PollInterval is the polling frequency suggested by the server, as
+	// maintained by PollIntervalHook. Use SuggestedPollInterval to read it
+	// while requests are in flight.
+	PollInterval time.Duration
+
+	config
+
+	skew       atomic.Int64
+	skewWarned atomic.Bool
+
+	mu sync.RWMutex
+}
+
+// config is the unexported configuration of a client, copied as a whole by
+// Clone. Pointer fields are shared between a client and its clones.
+type config struct {
+	responseHooks []ResponseHook
+	pool          *hostPool
+	gzipRequests  bool
+	gzipMinSize   int
+	decompress    bool
+	decoders      map[string]ContentDecoder
+	cache         Cache
+	pathAuth      []pathAuth
+
+	maxHeaderBytes     int
+	maxHeaderLineBytes int
+	maxResponseBytes   int64
+
+	stats   *clientStats
+	limiter *rateLimiter
+
+	transports        *sharedTransport
+	dns               *dnsResolver
+	transportSettings *TransportSettings
+	tlsConfig         *tls.Config
+	proxy             *url.URL
+
+	signer       RequestSigner
+	errors       *ErrorRegistry
+	errorDecoder ErrorDecoder
+	catalog      MessageCatalog
+	codecs       []Codec
+
+	uploadPolicy   *UploadPolicy
+	quarantineDir  string
+	downloadChecks []DownloadCheck
+	graphQLPath    string
+	pageStrategy   PageStrategy
+
+	logger        Logger
+	logBodyLimit  int
+	redactHeaders []string
+	redactQuery   []string
+	history       *requestHistory
+	flights       *flightGroup
+	migration     *Migration
+	dump          *debugDump
+	har           *HARRecorder
+	metrics       Metrics
+	templateFuncs template.FuncMap
+	transport     http.RoundTripper
+	keyLog        io.Writer
+	redirect      *RedirectPolicy
+	tap           *WireTap
+
+	idempotencyKeys bool
+	authProbe       string
+	userAgent       string
+	tenants         *tenantPools
+	maxTenants      int
+	correctSkew     bool
+	auth            AuthProvider
+	scopeHeaders    []string
+
+	// Guarded by RestHttp.mu.
+	retry    *RetryPolicy
+	logLevel LogLevel
 }
 
 func NewRestHttp(baseURL string, options ...func(*RestHttp)) *RestHttp {
@@ -87,22 +183,22 @@ func NewRestHttp(baseURL string, options ...func(*RestHttp)) *RestHttp {
 		VerifySSL:  true,
 		DebugPrint: false,
 		Timeout:    10 * time.Second,
+		config: config{
+			stats:      &clientStats{},
+			transports: &sharedTransport{},
+		},
 	}
-	fmt.Println("default user:", restHttp.User)
 
 	// Apply optional arguments
 	for _, option := range options {
 		option(restHttp)
 	}
-	fmt.Println("withUser value:", restHttp.User)
-	user := restHttp.User
-	password := restHttp.Password
-	headers := make(http.Header)
-	headers.Set("Accept", "application/json")
-	if user != "" && password != "" {
-		auth := user + ":" + password
-		authHeader := "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
-		headers.Set("Authorization", authHeader)
+
+	if restHttp.BaseHeaders == nil {
+		restHttp.BaseHeaders = make(http.Header)
+	}
+	if restHttp.BaseHeaders.Get("Accept") == "" {
+		restHttp.BaseHeaders.Set("Accept", "application/json")
 	}
 	return restHttp
 }
@@ -110,7 +206,6 @@ func NewRestHttp(baseURL string, options ...func(*RestHttp)) *RestHttp {
 func WithUser(user string) func(*RestHttp) {
 	return func(r *RestHttp) {
 		r.User = user
-		fmt.Println("user in WithUser:", user)
 	}
 }
 
@@ -126,51 +221,157 @@ func WithDebugPrint(debugPrint bool) func(*RestHttp) {
 	}
 }
 
+func WithResponseHook(hook ResponseHook) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.responseHooks = append(r.responseHooks, hook)
+	}
+}
+
 func (r *RestHttp) MakeURL(container string, resource string, queryItems url.Values) string {
-	parts := []string{r.BaseURL}
+	base := r.baseURL()
+	container = strings.Trim(container, "/")
+	var query string
+	if queryItems != nil {
+		query = queryItems.Encode()
+	}
 
+	var sb strings.Builder
+	sb.Grow(len(base) + len(container) + len(resource) + len(query) + 3)
+	sb.WriteString(base)
 	if container != "" {
-		parts = append(parts, strings.Trim(container, "/"))
+		sb.WriteString("/")
+		sb.WriteString(container)
+	}
+	sb.WriteString("/")
+	sb.WriteString(resource)
+	if queryItems != nil {
+		sb.WriteString("?")
+		sb.WriteString(query)
+	}
+	return sb.String()
+}
+
+func (r *RestHttp) HeadRequest(container string, resource string, opts ...RequestOption) (int, error) {
+	status, _, err := r.HeadRequestFull(container, resource, opts...)
+	return status, err
+}
+
+func (r *RestHttp) HeadRequestFull(container string, resource string, opts ...RequestOption) (int, http.Header, error) {
+	ro := newRequestOptions(opts)
+	url := r.MakeURL(container, resource, nil)
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return 0, nil, err
 	}
 
-	if resource != "" {
-		parts = append(parts, resource)
-	} else {
-		parts = append(parts, "")
+	if err := r.prepareRequest(req, ro); err != nil {
+		return 0, nil, err
 	}
 
-	urlStr := strings.Join(parts, "/")
+	resp, err := r.do(req, ro)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
 
-	if queryItems != nil {
-		urlStr += "?" + queryItems.Encode()
+	if r.debugEnabled() {
+		r.printRequest("HEAD", resp.Request.URL.String(), req.Header, nil)
 	}
 
-	return urlStr
+	return resp.StatusCode, resp.Header, nil
+}
+
+type OptionsResult struct {
+	StatusCode       int
+	Allow            []string
+	AllowOrigin      string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+	Header           http.Header
+}
+
+func (o *OptionsResult) Allows(method string) bool {
+	for _, m := range o.Allow {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	for _, m := range o.AllowMethods {
+		if m == "*" || strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
 }
 
-func (r *RestHttp) HeadRequest(container string, resource string) (int, error) {
+func (r *RestHttp) OptionsRequest(container string, resource string, opts ...RequestOption) (*OptionsResult, error) {
+	ro := newRequestOptions(opts)
 	url := r.MakeURL(container, resource, nil)
-	req, err := http.NewRequest("HEAD", url, nil)
+	req, err := http.NewRequest("OPTIONS", url, nil)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	r.setHeaders(req)
+	if err := r.prepareRequest(req, ro); err != nil {
+		return nil, err
+	}
 
-	client := r.createHttpClient()
-	resp, err := client.Do(req)
+	resp, err := r.do(req, ro)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
 
-	if r.DebugPrint {
-		r.printRequest("HEAD", resp.Request.URL.String(), req.Header, nil)
+	if r.debugEnabled() {
+		r.printRequest("OPTIONS", resp.Request.URL.String(), req.Header, nil)
+	}
+
+	return parseOptionsResponse(resp), nil
+}
+
+func parseOptionsResponse(resp *http.Response) *OptionsResult {
+	result := &OptionsResult{
+		StatusCode:    resp.StatusCode,
+		Allow:         splitHeaderList(resp.Header.Values("Allow")),
+		AllowOrigin:   resp.Header.Get("Access-Control-Allow-Origin"),
+		AllowMethods:  splitHeaderList(resp.Header.Values("Access-Control-Allow-Methods")),
+		AllowHeaders:  splitHeaderList(resp.Header.Values("Access-Control-Allow-Headers")),
+		ExposeHeaders: splitHeaderList(resp.Header.Values("Access-Control-Expose-Headers")),
+		Header:        resp.Header,
+	}
+
+	result.AllowCredentials = strings.EqualFold(strings.TrimSpace(resp.Header.Get("Access-Control-Allow-Credentials")), "true")
+
+	if maxAge := strings.TrimSpace(resp.Header.Get("Access-Control-Max-Age")); maxAge != "" {
+		if seconds, err := strconv.Atoi(maxAge); err == nil && seconds >= 0 {
+			result.MaxAge = time.Duration(seconds) * time.Second
+		}
 	}
 
-	return resp.StatusCode, nil
+	return result
 }
 
-func (r *RestHttp) GetRequest(container string, resource string, queryItems url.Values, accept string, toLower bool) ([]byte, error) {
+// splitHeaderList splits comma separated header values such as Allow into
+// their individual, trimmed elements.
+func splitHeaderList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+func (r *RestHttp) GetRequest(container string, resource string, queryItems url.Values, accept string, toLower bool, opts ...RequestOption) ([]byte, error) {
+	ro := newRequestOptions(opts)
 	url := r.MakeURL(container, resource, queryItems)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -181,20 +382,25 @@ func (r *RestHttp) GetRequest(container string, resource string, queryItems url.
 		req.Header.Set("Accept", accept)
 	}
 
-	r.setHeaders(req)
+	if err := r.prepareRequest(req, ro); err != nil {
+		return nil, err
+	}
 
-	client := r.createHttpClient()
-	resp, err := client.Do(req)
+	resp, err := r.do(req, ro)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if r.DebugPrint {
+	if r.debugEnabled() {
 		r.printRequest("GET", resp.Request.URL.String(), req.Header, nil)
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	if err := r.handleResponse(resp, ro); err != nil {
+		return nil, err
+	}
+
+	body, err := r.readBody(resp, ro)
 	if err != nil {
 		return nil, err
 	}
@@ -202,100 +408,299 @@ func (r *RestHttp) GetRequest(container string, resource string, queryItems url.
 	return body, nil
 }
 
-func (r *RestHttp) setHeaders(req *http.Request) {
-	for key, values := range r.BaseHeaders {
-		for _, value := range values {
-			req.Header.Add(key, value)
-		}
+// execute performs a request on behalf of the higher level APIs and returns
+// the fully read response.
+func (r *RestHttp) execute(ctx context.Context, method string, url string, body io.Reader, opts []RequestOption) (*Response, error) {
+	ro := newRequestOptions(opts)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func (r *RestHttp) createHttpClient() *http.Client {
-	client := &http.Client{
-		Timeout: r.Timeout,
+	if err := r.prepareRequest(req, ro); err != nil {
+		return nil, err
 	}
 
-	if !r.VerifySSL {
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
+	resp, err := r.do(req, ro)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if r.debugEnabled() {
+		r.printRequest(method, resp.Request.URL.String(), req.Header, nil)
+	}
+
+	if err := r.checkExpectedStatus(resp, ro); err != nil {
+		return nil, err
+	}
+
+	data, err := r.readBody(resp, ro)
+	if err != nil {
+		return nil, err
 	}
 
-	return client
+	response := newResponse(resp, data)
+	response.codecs = r.codecs
+	response.expected = ro.expectedStatus
+	return response, nil
 }
 
-func (r *RestHttp) printRequest(method string, url string, headers http.Header, body []byte) {
-	fmt.Println("Request:")
-	fmt.Println("Method:", method)
-	fmt.Println("URL:", url)
-	fmt.Println("Headers:")
-	for key, values := range headers {
-		for _, value := range values {
-			fmt.Printf("%s: %s\n", key, value)
-		}
+// do is the single path every request of the client goes through.
+func (r *RestHttp) do(req *http.Request, ro *requestOptions) (*http.Response, error) {
+	req, finish := withDeadline(req, ro)
+	if r.migration != nil {
+		return finish(r.migratedDo(req, ro))
 	}
-	fmt.Println("Body:", string(body))
+	return finish(r.dispatch(req, ro))
 }
 
-func (r *RestHttp) PostRequest(container string, resource string, params url.Values, accept string) ([]byte, error) {
-	url := r.MakeURL(container, resource, nil)
-	req, err := http.NewRequest("POST", url, strings.NewReader(params.Encode()))
-	if err != nil {
+// dispatch sends req, sharing it with identical requests in flight when
+// coalescing is enabled.
+func (r *RestHttp) dispatch(req *http.Request, ro *requestOptions) (*http.Response, error) {
+	if r.coalescable(req, ro) {
+		return r.coalescedDo(req, ro)
+	}
+	return r.roundTrip(req, ro)
+}
+
+// roundTrip sends req through the cache, when it is cacheable, and the
+// retry loop.
+func (r *RestHttp) roundTrip(req *http.Request, ro *requestOptions) (*http.Response, error) {
+	if r.cache != nil && req.Method == "GET" && !ro.keepEncoded && !ro.stream {
+		return r.cachedDo(req, ro)
+	}
+	return r.send(req, ro)
+}
+
+// sendOnce puts req on the wire and gives every registered response hook a
+// chance to inspect the response before it is handed back to the caller.
+func (r *RestHttp) sendOnce(req *http.Request, ro *requestOptions) (*http.Response, error) {
+	if err := r.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	if err := r.signRequest(req); err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	if accept != "" {
-		req.Header.Set("Accept", accept)
+	if err := r.checkHeaderLimits(req); err != nil {
+		return nil, err
 	}
 
-	r.setHeaders(req)
+	r.count(func(s *clientStats) {
+		s.requests.Add(1)
+		s.inFlight.Add(1)
+	})
+	defer r.count(func(s *clientStats) { s.inFlight.Add(-1) })
+
+	if ro.redirectChain != nil {
+		*ro.redirectChain = nil
+	}
 
 	client := r.createHttpClient()
-	resp, err := client.Do(req)
-	if err != nil {
+	client.CheckRedirect = r.checkRedirect(ro)
+	r.tenantClient(client, req, ro)
+	if tapped := r.tapTransport(req); tapped != nil {
+		client.Transport = tapped
+	}
+	if ro.stream || ro.timeout > 0 {
+		// Streams legitimately outlive the overall request timeout, and
+		// per-request timeouts are enforced through the context.
+		client.Timeout = 0
+	}
+
+	if err := r.dumpRequest(req, ro); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if r.DebugPrint {
-		r.printRequest("POST", resp.Request.URL.String(), req.Header, []byte(params.Encode()))
+	record, err := r.recordRequest(req)
+	if err != nil {
+		return nil, err
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	finish := r.measure(req)
+	start := time.Now()
+	attempt, headersDone := r.awaitHeaders(req, ro)
+	resp, err := client.Do(r.traceConns(attempt))
+	resp, err = headersDone(resp, err)
+	if finish != nil {
+		finish(resp, err)
+	}
+	r.remember(req, resp, start, err)
 	if err != nil {
+		if record != nil {
+			record(nil, err)
+		}
+		r.count(func(s *clientStats) { s.errors.Add(1) })
+		r.log(LogError, "request failed", "method", req.Method, "url", r.redactedURL(req.URL), "error", r.redactedError(req, err))
 		return nil, err
 	}
 
-	return body, nil
+	r.count(func(s *clientStats) { s.latencies.add(time.Since(start)) })
+	r.observeClock(resp, start)
+
+	if r.pool != nil {
+		r.pool.observe(resp)
+	}
+
+	for _, hook := range r.responseHooks {
+		hook(r, resp)
+	}
+
+	if r.decompress && !ro.keepEncoded {
+		if err := r.decodeResponse(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	if record != nil {
+		record(resp, nil)
+	}
+
+	if err := r.dumpResponse(resp, ro); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	r.logResponse(req, resp, time.Since(start), ro)
+	return resp, nil
 }
 
-func (r *RestHttp) PutRequest(container string, resource string, params url.Values, accept string) ([]byte, error) {
-	url := r.MakeURL(container, resource, nil)
-	req, err := http.NewRequest("PUT", url, strings.NewReader(params.Encode()))
+// setHeaders copies BaseHeaders onto req, leaving headers the calling method
+// has already set untouched.
+func (r *RestHttp) setHeaders(req *http.Request) {
+	for key, values := range r.baseHeaders() {
+		key = http.CanonicalHeaderKey(key)
+		if _, ok := req.Header[key]; ok {
+			continue
+		}
+		// Share the values instead of copying them; the clipped slice keeps
+		// later additions from writing into BaseHeaders.
+		req.Header[key] = values[:len(values):len(values)]
+	}
+}
+
+// prepareRequest applies the client defaults and then the per-request
+// options to req. Per-request options always win.
+func (r *RestHttp) prepareRequest(req *http.Request, ro *requestOptions) error {
+	if ro.err != nil {
+		return ro.err
+	}
+	if k, ok := r.keyLog.(*keyLogFile); ok {
+		if err := k.open(); err != nil {
+			return err
+		}
+	}
+
+	r.setHeaders(req)
+
+	if auth := r.authFor(req, ro); auth != nil {
+		if err := auth.Authenticate(r.withAuthClient(req)); err != nil {
+			return err
+		}
+	}
+
+	for key, values := range ro.header {
+		req.Header[key] = values[:len(values):len(values)]
+	}
+
+	r.setUserAgent(req, ro)
+
+	if len(ro.query) > 0 {
+		query := req.URL.Query()
+		for key, values := range ro.query {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+
+	r.setIdempotencyKey(req)
+
+	if r.pool != nil {
+		r.pool.route(req, r.baseURL(), ro)
+	}
+
+	if ro.noAuth {
+		req.Header.Del("Authorization")
+		req.Header.Del("Cookie")
+	}
+
+	if r.decompress && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", r.acceptEncoding())
+	}
+
+	return r.compressRequest(req)
+}
+
+// createHttpClient returns a client for one request. The client itself is
+// cheap; the transport, and with it the connection pool, is shared.
+func (r *RestHttp) createHttpClient() *http.Client {
+	return &http.Client{
+		Timeout:   r.timeout(),
+		Transport: r.httpTransport(),
+	}
+}
+
+func (r *RestHttp) PostRequest(container string, resource string, params url.Values, accept string, opts ...RequestOption) ([]byte, error) {
+	return r.sendParams("POST", container, resource, params, accept, opts)
+}
+
+func (r *RestHttp) PutRequest(container string, resource string, params url.Values, accept string, opts ...RequestOption) ([]byte, error) {
+	return r.sendParams("PUT", container, resource, params, accept, opts)
+}
+
+func (r *RestHttp) sendParams(method string, container string, resource string, params url.Values, accept string, opts []RequestOption) ([]byte, error) {
+	ro := newRequestOptions(opts)
+	serialize := ro.paramsSerializer
+	if serialize == nil {
+		serialize = FormParams
+		if contentType := ro.header.Get("Content-Type"); contentType != "" {
+			if codec, err := codecFor(r.codecs, contentType); err == nil {
+				serialize = CodecParams(codec)
+			}
+		}
+	}
+
+	data, contentType, query, err := serialize(params)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	url := r.MakeURL(container, resource, query)
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 	if accept != "" {
 		req.Header.Set("Accept", accept)
 	}
 
-	r.setHeaders(req)
+	if err := r.prepareRequest(req, ro); err != nil {
+		return nil, err
+	}
 
-	client := r.createHttpClient()
-	resp, err := client.Do(req)
+	resp, err := r.do(req, ro)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if r.DebugPrint {
-		r.printRequest("PUT", resp.Request.URL.String(), req.Header, []byte(params.Encode()))
+	if r.debugEnabled() {
+		r.printRequest(method, resp.Request.URL.String(), req.Header, data)
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	if err := r.handleResponse(resp, ro); err != nil {
+		return nil, err
+	}
+
+	body, err := r.readBody(resp, ro)
 	if err != nil {
 		return nil, err
 	}
@@ -303,7 +708,8 @@ func (r *RestHttp) PutRequest(container string, resource string, params url.Valu
 	return body, nil
 }
 
-func (r *RestHttp) DeleteRequest(container string, resource string, queryItems url.Values, accept string) ([]byte, error) {
+func (r *RestHttp) DeleteRequest(container string, resource string, queryItems url.Values, accept string, opts ...RequestOption) ([]byte, error) {
+	ro := newRequestOptions(opts)
 	url := r.MakeURL(container, resource, queryItems)
 	req, err := http.NewRequest("DELETE", url, nil)
 	if err != nil {
@@ -314,20 +720,25 @@ func (r *RestHttp) DeleteRequest(container string, resource string, queryItems u
 		req.Header.Set("Accept", accept)
 	}
 
-	r.setHeaders(req)
+	if err := r.prepareRequest(req, ro); err != nil {
+		return nil, err
+	}
 
-	client := r.createHttpClient()
-	resp, err := client.Do(req)
+	resp, err := r.do(req, ro)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if r.DebugPrint {
+	if r.debugEnabled() {
 		r.printRequest("DELETE", resp.Request.URL.String(), req.Header, nil)
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	if err := r.handleResponse(resp, ro); err != nil {
+		return nil, err
+	}
+
+	body, err := r.readBody(resp, ro)
 	if err != nil {
 		return nil, err
 	}
@@ -335,65 +746,70 @@ func (r *RestHttp) DeleteRequest(container string, resource string, queryItems u
 	return body, nil
 }
 
-func (r *RestHttp) DownloadFile(container string, resource string, savePath string, accept string, queryItems url.Values) error {
+func (r *RestHttp) DownloadFile(container string, resource string, savePath string, accept string, queryItems url.Values, opts ...RequestOption) error {
+	ro := newRequestOptions(opts)
 	resource = strings.ReplaceAll(resource, "\\", "/")
 	url := r.MakeURL(container, resource, queryItems)
 	if savePath == "" {
 		savePath = strings.Split(resource, "/")[len(strings.Split(resource, "/"))-1]
 	}
 
-	headers := r.BaseHeaders.Clone()
-	if accept != "" {
-		headers.Set("Accept", accept)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
 	}
 
-	if queryItems != nil && len(queryItems) > 0 {
-		url += "?" + queryItems.Encode()
-		queryItems = nil
+	if accept == "" {
+		accept = "*/*"
 	}
+	req.Header.Set("Accept", accept)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
+	// Negotiate encodings ourselves so the transport never decodes behind
+	// our back; compressed artifacts must be stored exactly as served.
+	req.Header.Set("Accept-Encoding", r.acceptEncoding())
+	ro.keepEncoded = true
+
+	if err := r.prepareRequest(req, ro); err != nil {
 		return err
 	}
 
-	r.setHeaders(req)
-
-	client := r.createHttpClient()
-	resp, err := client.Do(req)
+	resp, err := r.do(req, ro)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		return NewRestHttpError(resp.StatusCode, resp.Status, "", "")
+	if err := r.handleResponse(resp, ro); err != nil {
+		return err
 	}
 
-	fileSizeDl := 0
-	file, err := os.Create(savePath)
-	if err != nil {
-		return fmt.Errorf("could not create file: %s", err)
+	if !isCompressedArtifact(resp, savePath) {
+		if err := r.decodeResponse(resp); err != nil {
+			return err
+		}
+		defer resp.Body.Close()
 	}
-	defer file.Close()
 
-	_, err = io.Copy(file, resp.Body)
+	fileSizeDl, err := r.saveDownload(resp.Body, savePath, resp.Header, ro)
 	if err != nil {
-		return fmt.Errorf("could not download file: %s", err)
+		return err
 	}
 
-	if r.DebugPrint {
-		fmt.Printf("===> downloaded %d bytes to %s\n", fileSizeDl, savePath)
-	}
+	r.log(LogDebug, "downloaded file", "bytes", fileSizeDl, "path", savePath)
 
 	return nil
 }
 
-func (r *RestHttp) UploadFile(container string, resource string, params url.Values, contentType string, file *os.File) ([]byte, error) {
+func (r *RestHttp) UploadFile(container string, resource string, params url.Values, contentType string, file *os.File, opts ...RequestOption) ([]byte, error) {
+	ro := newRequestOptions(opts)
 	url := r.MakeURL(container, resource, nil)
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
+	if err := r.checkUpload(file, filepath.Base(file.Name())); err != nil {
+		return nil, err
+	}
+
 	// Add file
 	part, err := writer.CreateFormFile("file", filepath.Base(file.Name()))
 	if err != nil {
@@ -423,32 +839,39 @@ func (r *RestHttp) UploadFile(container string, resource string, params url.Valu
 	}
 
 	req.Header.Set("Content-Type", writer.FormDataContentType())
-	r.setHeaders(req)
+	if err := r.prepareRequest(req, ro); err != nil {
+		return nil, err
+	}
 
-	client := r.createHttpClient()
-	resp, err := client.Do(req)
+	resp, err := r.do(req, ro)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if r.DebugPrint {
+	if r.debugEnabled() {
 		r.printRequest("POST", resp.Request.URL.String(), req.Header, nil)
 	}
 
-	respBody, err := ioutil.ReadAll(resp.Body)
+	if err := r.handleResponse(resp, ro); err != nil {
+		return nil, err
+	}
+
+	respBody, err := r.readBody(resp, ro)
 	if err != nil {
 		return nil, err
 	}
 
 	return respBody, nil
 }
-func (r *RestHttp) handleResponse(resp *http.Response) error {
-	if resp.StatusCode >= 300 {
-		return NewRestHttpError(resp.StatusCode, resp.Status, "", "")
-	}
 
-	return nil
+// handleResponse turns unsuccessful responses into errors, unless the caller
+// asked for the raw response with WithAnyStatus.
+func (r *RestHttp) handleResponse(resp *http.Response, ro *requestOptions) error {
+	if ro.anyStatus || ro.acceptsStatus(resp.StatusCode) {
+		return nil
+	}
+	return r.responseError(resp)
 }
 
 func fileExists(path string) bool {
@@ -456,7 +879,8 @@ func fileExists(path string) bool {
 	return !os.IsNotExist(err)
 }
 
-func (r *RestHttp) UploadFileMP(container string, srcFilePath string, dstName string, contentType string) ([]byte, error) {
+func (r *RestHttp) UploadFileMP(container string, srcFilePath string, dstName string, contentType string, opts ...RequestOption) ([]byte, error) {
+	ro := newRequestOptions(opts)
 	if !fileExists(srcFilePath) {
 		return nil, fmt.Errorf("file not found: %s", srcFilePath)
 	}
@@ -479,6 +903,10 @@ func (r *RestHttp) UploadFileMP(container string, srcFilePath string, dstName st
 	}
 	defer file.Close()
 
+	if err := r.checkUpload(file, dstName); err != nil {
+		return nil, err
+	}
+
 	part, err := writer.CreateFormFile("file", dstName)
 	if err != nil {
 		return nil, err
@@ -498,20 +926,25 @@ func (r *RestHttp) UploadFileMP(container string, srcFilePath string, dstName st
 	}
 
 	req.Header.Set("Content-Type", contentType)
-	r.setHeaders(req)
+	if err := r.prepareRequest(req, ro); err != nil {
+		return nil, err
+	}
 
-	client := r.createHttpClient()
-	resp, err := client.Do(req)
+	resp, err := r.do(req, ro)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if r.DebugPrint {
+	if r.debugEnabled() {
 		r.printRequest("POST", resp.Request.URL.String(), req.Header, nil)
 	}
 
-	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err := r.handleResponse(resp, ro); err != nil {
+		return nil, err
+	}
+
+	responseBody, err := r.readBody(resp, ro)
 	if err != nil {
 		return nil, err
 	}
@@ -519,17 +952,12 @@ func (r *RestHttp) UploadFileMP(container string, srcFilePath string, dstName st
 	return responseBody, nil
 }
 
-func (r *RestHttp) UploadFiles(container string, srcDstMap map[string]string, contentType string) ([]byte, error) {
+func (r *RestHttp) UploadFiles(container string, srcDstMap map[string]string, contentType string, opts ...RequestOption) ([]byte, error) {
 	if contentType == "" {
-		contentType = "application/octet.stream"
+		contentType = "application/octet-stream"
 	}
 
-	url := r.MakeURL(container, "", nil)
-	body := new(bytes.Buffer)
-	writer := multipart.NewWriter(body)
-
-	var fileCloseFuncs []func()
-
+	parts := make([]Part, 0, len(srcDstMap))
 	for srcPath, dstName := range srcDstMap {
 		if dstName == "" {
 			dstName = filepath.Base(srcPath)
@@ -539,57 +967,10 @@ func (r *RestHttp) UploadFiles(container string, srcDstMap map[string]string, co
 		if err != nil {
 			return nil, err
 		}
+		defer file.Close()
 
-		part, err := writer.CreateFormFile("files", dstName)
-		if err != nil {
-			file.Close()
-			return nil, err
-		}
-
-		_, err = io.Copy(part, file)
-		if err != nil {
-			file.Close()
-			return nil, err
-		}
-
-		fileCloseFuncs = append(fileCloseFuncs, func() { file.Close() })
+		parts = append(parts, FilePart{Field: "files", Filename: dstName, ContentType: contentType, Reader: file})
 	}
 
-	contentType = writer.FormDataContentType()
-	writer.Close()
-
-	req, err := http.NewRequest("POST", url, body)
-	if err != nil {
-		for _, fileCloseFunc := range fileCloseFuncs {
-			fileCloseFunc()
-		}
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", contentType)
-	r.setHeaders(req)
-
-	client := r.createHttpClient()
-	resp, err := client.Do(req)
-	if err != nil {
-		for _, fileCloseFunc := range fileCloseFuncs {
-			fileCloseFunc()
-		}
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if r.DebugPrint {
-		r.printRequest("POST", resp.Request.URL.String(), req.Header, nil)
-	}
-
-	responseBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		for _, fileCloseFunc := range fileCloseFuncs {
-			fileCloseFunc()
-		}
-		return nil, err
-	}
-
-	return responseBody, nil
+	return r.UploadParts(container, "", parts, opts...)
 }
@@ -0,0 +1,263 @@
+// Package resthttptest provides a programmable fake for code that depends on
+// resthttp. A Fake answers requests in process, without a server:
+//
+//	fake := resthttptest.New()
+//	fake.On("GET", "/users/42").ReplyJSON(200, map[string]string{"name": "ada"})
+//	fake.On("POST", "/users").Times(2).Reply(503, "")
+//	fake.On("POST", "/users").Reply(201, `{"id": 43}`)
+//
+//	svc := NewService(fake.Client())
+//	...
+//	if calls := fake.CallsTo("POST", "/users"); len(calls) != 3 { ... }
+package resthttptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rest/resthttp"
+)
+
+// BaseURL is the base URL of clients returned by Fake.Client.
+const BaseURL = "http://resthttptest.invalid"
+
+// Call records a request received by a Fake.
+type Call struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Fake is an http.RoundTripper serving stubbed routes. It is safe for
+// concurrent use.
+type Fake struct {
+	mu     sync.Mutex
+	routes []*Route
+	calls  []Call
+	client *resthttp.RestHttp
+}
+
+// New returns a fake whose client is configured with options. Requests that
+// match no route are answered with 404.
+func New(options ...func(*resthttp.RestHttp)) *Fake {
+	f := &Fake{}
+	options = append([]func(*resthttp.RestHttp){resthttp.WithTransport(f)}, options...)
+	f.client = resthttp.NewRestHttp(BaseURL, options...)
+	return f
+}
+
+// Client returns a client talking to the fake.
+func (f *Fake) Client() *resthttp.RestHttp {
+	return f.client
+}
+
+// On adds a route for method and path. A path ending in "*" matches every
+// path with that prefix. Routes are tried in the order they were added.
+func (f *Fake) On(method string, path string) *Route {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	route := &Route{method: strings.ToUpper(method), path: path, status: http.StatusOK, header: make(http.Header)}
+	f.routes = append(f.routes, route)
+	return route
+}
+
+// Calls returns every request received so far.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the requests received for method and path.
+func (f *Fake) CallsTo(method string, path string) []Call {
+	var calls []Call
+	for _, call := range f.Calls() {
+		if call.Method == strings.ToUpper(method) && call.Path == path {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset removes all routes and recorded calls.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes = nil
+	f.calls = nil
+}
+
+func (f *Fake) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+	route := f.match(req)
+	f.mu.Unlock()
+
+	if route == nil {
+		return respond(req, http.StatusNotFound, nil,
+			[]byte(fmt.Sprintf(`{"message": "resthttptest: no route for %s %s"}`, req.Method, req.URL.Path))), nil
+	}
+	return route.serve(req, body)
+}
+
+// match finds the first route for req with uses left and consumes one use.
+// The caller holds f.mu.
+func (f *Fake) match(req *http.Request) *Route {
+	for _, route := range f.routes {
+		if route.method != req.Method || !route.matches(req.URL.Path) {
+			continue
+		}
+		if route.times > 0 && route.served >= route.times {
+			continue
+		}
+		route.served++
+		return route
+	}
+	return nil
+}
+
+// Route is a stubbed response. Its methods configure it and return it for
+// chaining; they must not be called once requests are being served.
+type Route struct {
+	method  string
+	path    string
+	times   int
+	served  int
+	status  int
+	header  http.Header
+	body    []byte
+	delay   time.Duration
+	err     error
+	timeout bool
+	handler func(req *http.Request, body []byte) (*http.Response, error)
+}
+
+func (route *Route) matches(path string) bool {
+	if prefix := strings.TrimSuffix(route.path, "*"); prefix != route.path {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == route.path
+}
+
+// Reply answers with status and body.
+func (route *Route) Reply(status int, body string) *Route {
+	route.status = status
+	route.body = []byte(body)
+	return route
+}
+
+// ReplyJSON answers with status and v encoded as JSON.
+func (route *Route) ReplyJSON(status int, v interface{}) *Route {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("resthttptest: could not encode reply: %s", err))
+	}
+	route.header.Set("Content-Type", "application/json")
+	return route.Reply(status, string(data))
+}
+
+// ReplyFunc computes the response from the request and its body.
+func (route *Route) ReplyFunc(fn func(req *http.Request, body []byte) (*http.Response, error)) *Route {
+	route.handler = fn
+	return route
+}
+
+// Header adds a response header.
+func (route *Route) Header(key string, value string) *Route {
+	route.header.Add(key, value)
+	return route
+}
+
+// Times limits the route to n requests, after which later routes for the
+// same method and path apply. Zero means unlimited.
+func (route *Route) Times(n int) *Route {
+	route.times = n
+	return route
+}
+
+// Delay holds the response back, honouring the request's context so that
+// client timeouts fire as they would against a slow server.
+func (route *Route) Delay(d time.Duration) *Route {
+	route.delay = d
+	return route
+}
+
+// Fail makes the transport return err instead of a response.
+func (route *Route) Fail(err error) *Route {
+	route.err = err
+	return route
+}
+
+// Timeout makes the request hang until the client gives up on it.
+func (route *Route) Timeout() *Route {
+	route.timeout = true
+	return route
+}
+
+func (route *Route) serve(req *http.Request, body []byte) (*http.Response, error) {
+	if route.timeout {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+	if route.delay > 0 {
+		timer := time.NewTimer(route.delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	if route.err != nil {
+		return nil, route.err
+	}
+	if route.handler != nil {
+		return route.handler(req, body)
+	}
+	return respond(req, route.status, route.header, route.body), nil
+}
+
+func respond(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	h := header.Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,74 @@
+package resthttptest
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFakeConcurrent serves routes to concurrent clients and is meant to be
+// run with -race.
+func TestFakeConcurrent(t *testing.T) {
+	errDown := errors.New("connection refused")
+	tests := []struct {
+		name     string
+		setup    func(f *Fake)
+		wantErrs int
+	}{
+		{
+			name: "reply",
+			setup: func(f *Fake) {
+				f.On("GET", "/items/*").Reply(200, "ok")
+			},
+		},
+		{
+			name: "limited failures",
+			setup: func(f *Fake) {
+				f.On("GET", "/items/*").Times(5).Fail(errDown)
+				f.On("GET", "/items/*").Delay(time.Millisecond).Reply(200, "ok")
+			},
+			wantErrs: 5,
+		},
+		{
+			name: "limited server errors",
+			setup: func(f *Fake) {
+				f.On("GET", "/items/*").Times(3).Reply(503, "")
+				f.On("GET", "/items/*").ReplyJSON(200, map[string]int{"id": 1})
+			},
+			wantErrs: 3,
+		},
+	}
+
+	const n = 32
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			tt.setup(f)
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			errs := 0
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					if _, err := f.Client().GetRequest("items", strconv.Itoa(i), nil, "", false); err != nil {
+						mu.Lock()
+						errs++
+						mu.Unlock()
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			if errs != tt.wantErrs {
+				t.Errorf("got %d errors, want %d", errs, tt.wantErrs)
+			}
+			if calls := len(f.Calls()); calls != n {
+				t.Errorf("recorded %d calls, want %d", calls, n)
+			}
+		})
+	}
+}
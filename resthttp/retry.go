@@ -0,0 +1,181 @@
+package resthttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"rest/resthttp/backoff"
+)
+
+// RetryPolicy retries failed requests with exponential backoff and full
+// jitter, as implemented by the backoff package. Only idempotent methods,
+// and requests carrying an Idempotency-Key, are retried, and only on the
+// statuses in RetryOn or transient network errors.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// InitialBackoff defaults to 100ms, MaxBackoff to 10s.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryOn lists the statuses worth retrying. Defaults to 429, 502, 503
+	// and 504.
+	RetryOn []int
+	// ExcludeMethods opts methods out of retries, such as latency sensitive
+	// GETs on a client that otherwise retries.
+	ExcludeMethods []string
+}
+
+func WithRetryPolicy(policy *RetryPolicy) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.retry = policy
+	}
+}
+
+var defaultRetryStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+var idempotentMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"OPTIONS": true,
+	"TRACE":   true,
+	"PUT":     true,
+	"DELETE":  true,
+}
+
+func (p *RetryPolicy) allows(req *http.Request) bool {
+	for _, method := range p.ExcludeMethods {
+		if strings.EqualFold(method, req.Method) {
+			return false
+		}
+	}
+	return idempotentMethods[req.Method] || req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// WithNoRetry sends this request exactly once regardless of the client's
+// retry policy.
+func WithNoRetry() RequestOption {
+	return func(ro *requestOptions) {
+		ro.noRetry = true
+	}
+}
+
+func (p *RetryPolicy) retryStatus(status int) bool {
+	statuses := p.RetryOn
+	if statuses == nil {
+		statuses = defaultRetryStatuses
+	}
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return transientError(err)
+	}
+	return p.retryStatus(resp.StatusCode)
+}
+
+// transientError reports whether err is a network failure that may not
+// recur: connection resets and refusals, timeouts and truncated responses.
+// Invalid requests, certificate problems and errors raised by the client
+// itself, such as rejected uploads, are permanent.
+func transientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) || errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return false
+	}
+	if errors.Is(err, ErrHeaderTimeout) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	// *url.Error is itself a net.Error, so look at what it wraps.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// backoff returns the pause before the given retry (1 for the first retry),
+// preferring the server's Retry-After when it asks for longer.
+func (p *RetryPolicy) backoff(retry int, resp *http.Response) time.Duration {
+	policy := backoff.Exponential{Initial: p.InitialBackoff, Max: p.MaxBackoff}
+	max := policy.MaxDelay()
+	wait := policy.Delay(retry)
+
+	if resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok && retryAfter > wait {
+			wait = retryAfter
+			if wait > max {
+				wait = max
+			}
+		}
+	}
+	return wait
+}
+
+// send performs req, retrying it according to the client's RetryPolicy.
+func (r *RestHttp) send(req *http.Request, ro *requestOptions) (*http.Response, error) {
+	policy := r.retryPolicy()
+	if ro.noRetry || policy == nil || policy.MaxAttempts <= 1 || !policy.allows(req) {
+		return r.sendOnce(req, ro)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := r.sendOnce(req, ro)
+		if attempt >= policy.MaxAttempts || !policy.shouldRetry(resp, err) {
+			return resp, err
+		}
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, err
+		}
+
+		wait := policy.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		if err := backoff.Sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		next := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			next.Body = body
+		}
+		req = next
+
+		r.count(func(s *clientStats) { s.retries.Add(1) })
+	}
+}
@@ -0,0 +1,64 @@
+package resthttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", &url.Error{Op: "Get", URL: "http://x", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, true},
+		{"connection reset", &url.Error{Op: "Get", URL: "http://x", Err: fmt.Errorf("read: %w", syscall.ECONNRESET)}, true},
+		{"unexpected EOF", &url.Error{Op: "Get", URL: "http://x", Err: io.ErrUnexpectedEOF}, true},
+		{"header timeout", fmt.Errorf("GET x: %w", ErrHeaderTimeout), true},
+		{"canceled", &url.Error{Op: "Get", URL: "http://x", Err: context.Canceled}, false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"unsupported scheme", &url.Error{Op: "Get", URL: "ftp://x", Err: errors.New("unsupported protocol scheme")}, false},
+		{"rejected upload", &UploadRejectedError{Name: "a", Reason: "too large"}, false},
+		{"body limit", fmt.Errorf("GET x: %w", ErrResponseTooLarge), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transientError(tt.err); got != tt.want {
+				t.Errorf("transientError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryCertificateError(t *testing.T) {
+	var served atomic.Int64
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		served.Add(1)
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer srv.Close()
+
+	r := NewRestHttp(srv.URL,
+		WithRetryPolicy(&RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+		WithHistory(8))
+	if _, err := r.GetRequest("items", "1", nil, "", false); err == nil {
+		t.Fatal("request to a server with an untrusted certificate succeeded")
+	}
+	if n := len(r.History()); n != 1 {
+		t.Errorf("made %d attempts, want 1", n)
+	}
+	if n := served.Load(); n != 0 {
+		t.Errorf("server handled %d requests, want none", n)
+	}
+}
@@ -0,0 +1,156 @@
+package resthttp
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LogLevel controls how much the client reports about its traffic.
+type LogLevel int
+
+const (
+	LogOff LogLevel = iota
+	LogError
+	LogInfo
+	LogDebug
+)
+
+// The setters below may be called while requests are in flight; they take
+// effect for subsequent requests. The exported fields they correspond to are
+// only meant to be set before the client is first used.
+
+func (r *RestHttp) SetBaseURL(baseURL string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.BaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetBaseHeader replaces the value of a header sent with every request. The
+// headers are copied on write, so requests in flight keep the previous set.
+func (r *RestHttp) SetBaseHeader(key string, value string) {
+	r.updateBaseHeaders(func(header http.Header) { header.Set(key, value) })
+}
+
+func (r *RestHttp) AddBaseHeader(key string, value string) {
+	r.updateBaseHeaders(func(header http.Header) { header.Add(key, value) })
+}
+
+func (r *RestHttp) DelBaseHeader(key string) {
+	r.updateBaseHeaders(func(header http.Header) { header.Del(key) })
+}
+
+// SetBaseHeaders replaces all headers sent with every request.
+func (r *RestHttp) SetBaseHeaders(header http.Header) {
+	r.updateBaseHeaders(func(current http.Header) {
+		for key := range current {
+			delete(current, key)
+		}
+		for key, values := range header {
+			current[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	})
+}
+
+func (r *RestHttp) updateBaseHeaders(update func(http.Header)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	header := r.BaseHeaders.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	update(header)
+	r.BaseHeaders = header
+}
+
+// SetCredentials replaces the user and password used for basic auth.
+func (r *RestHttp) SetCredentials(user string, password string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.User = user
+	r.Password = password
+}
+
+func (r *RestHttp) SetTimeout(timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Timeout = timeout
+}
+
+// SetRetryPolicy replaces the retry policy; nil disables retries.
+func (r *RestHttp) SetRetryPolicy(policy *RetryPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retry = policy
+}
+
+func (r *RestHttp) SetLogLevel(level LogLevel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logLevel = level
+}
+
+func WithLogLevel(level LogLevel) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.logLevel = level
+	}
+}
+
+func (r *RestHttp) baseURL() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.BaseURL
+}
+
+// baseHeaders returns the current base headers. The map is never modified
+// by the Set methods and must not be modified by the caller.
+func (r *RestHttp) baseHeaders() http.Header {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.BaseHeaders
+}
+
+func (r *RestHttp) credentials() (string, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.User, r.Password
+}
+
+func (r *RestHttp) timeout() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.Timeout
+}
+
+func (r *RestHttp) retryPolicy() *RetryPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.retry
+}
+
+func (r *RestHttp) currentLogLevel() LogLevel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.DebugPrint && r.logLevel < LogDebug {
+		return LogDebug
+	}
+	return r.logLevel
+}
+
+func (r *RestHttp) debugEnabled() bool {
+	return r.currentLogLevel() >= LogDebug
+}
+
+// SuggestedPollInterval returns the interval last announced by the server
+// through PollIntervalHook.
+func (r *RestHttp) SuggestedPollInterval() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.PollInterval
+}
+
+func (r *RestHttp) setPollInterval(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.PollInterval = interval
+}
@@ -0,0 +1,199 @@
+package resthttp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a message received from a text/event-stream.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	// Retry is the reconnection delay requested by the server, if any.
+	Retry time.Duration
+}
+
+// defaultSSERetry is the reconnection delay used until the server sends a
+// retry field.
+const defaultSSERetry = 3 * time.Second
+
+// SubscribeSSE connects to a Server-Sent Events endpoint and calls handler
+// for every event. Dropped connections are re-established after the server's
+// retry delay, sending Last-Event-ID so no events are missed. It returns nil
+// when ctx is cancelled or the server answers 204 No Content, the error
+// returned by handler, or any error other than a dropped stream or a
+// transient network failure, such as a non-2xx status or an invalid request.
+func (r *RestHttp) SubscribeSSE(ctx context.Context, container string, resource string, handler func(Event) error, opts ...RequestOption) error {
+	url := r.MakeURL(container, resource, nil)
+	lastEventID := ""
+	retry := defaultSSERetry
+
+	for {
+		err := r.streamEvents(ctx, url, lastEventID, opts, func(event Event) error {
+			if event.Retry > 0 {
+				retry = event.Retry
+			}
+			lastEventID = event.ID
+			if event.Data == "" && event.Event == "" {
+				return nil
+			}
+			return handler(event)
+		})
+		if err == errStreamDone {
+			return nil
+		}
+		var handlerErr *sseHandlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		var streamErr *sseStreamError
+		if !errors.As(err, &streamErr) && !transientError(err) {
+			return err
+		}
+
+		timer := time.NewTimer(retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+var errStreamDone = errors.New("event stream closed by server")
+
+type sseHandlerError struct {
+	err error
+}
+
+func (e *sseHandlerError) Error() string { return e.err.Error() }
+
+// sseStreamError is a failure reading an established stream, after which
+// the client reconnects.
+type sseStreamError struct {
+	err error
+}
+
+func (e *sseStreamError) Error() string { return e.err.Error() }
+
+// streamEvents runs a single connection of an event stream.
+func (r *RestHttp) streamEvents(ctx context.Context, url string, lastEventID string, opts []RequestOption, fn func(Event) error) error {
+	ro := newRequestOptions(opts)
+	ro.stream = true
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	if err := r.prepareRequest(req, ro); err != nil {
+		return err
+	}
+
+	resp, err := r.do(req, ro)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if r.debugEnabled() {
+		r.printRequest("GET", resp.Request.URL.String(), req.Header, nil)
+	}
+
+	if resp.StatusCode == http.StatusNoContent {
+		return errStreamDone
+	}
+	if !ro.acceptsStatus(resp.StatusCode) {
+		return r.responseError(resp)
+	}
+
+	err = parseEventStream(resp.Body, lastEventID, func(event Event) error {
+		if err := fn(event); err != nil {
+			return &sseHandlerError{err: err}
+		}
+		return nil
+	})
+	var handlerErr *sseHandlerError
+	if err != nil && !errors.As(err, &handlerErr) {
+		return &sseStreamError{err: err}
+	}
+	return err
+}
+
+// parseEventStream implements the event stream interpretation rules of the
+// HTML living standard.
+func parseEventStream(body io.Reader, lastEventID string, fn func(Event) error) error {
+	reader := bufio.NewReader(body)
+	var data strings.Builder
+	event := Event{ID: lastEventID}
+	first := true
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+
+		if line == "" {
+			if data.Len() > 0 || event.Retry > 0 {
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				if event.Event == "" && event.Data != "" {
+					event.Event = "message"
+				}
+				if err := fn(event); err != nil {
+					return err
+				}
+			}
+			data.Reset()
+			event = Event{ID: event.ID}
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				event.ID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				event.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
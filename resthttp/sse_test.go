@@ -0,0 +1,76 @@
+package resthttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubscribeSSEErrors(t *testing.T) {
+	errStop := errors.New("stop")
+	tests := []struct {
+		name string
+		// handler serves connection n, counting from 1.
+		handler   func(w http.ResponseWriter, n int64)
+		opts      []RequestOption
+		want      error
+		wantConns int64
+	}{
+		{
+			name: "reconnects after a dropped stream",
+			handler: func(w http.ResponseWriter, n int64) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprintf(w, "retry: 1\nid: %d\ndata: event %d\n\n", n, n)
+			},
+			want:      errStop,
+			wantConns: 2,
+		},
+		{
+			name: "status error",
+			handler: func(w http.ResponseWriter, n int64) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantConns: 1,
+		},
+		{
+			name:    "invalid request",
+			handler: func(w http.ResponseWriter, n int64) {},
+			opts: []RequestOption{func(ro *requestOptions) {
+				ro.err = errors.New("invalid option")
+			}},
+			wantConns: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conns atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				tt.handler(w, conns.Add(1))
+			}))
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := NewRestHttp(srv.URL).SubscribeSSE(ctx, "events", "", func(event Event) error {
+				if event.ID == "2" {
+					return errStop
+				}
+				return nil
+			}, tt.opts...)
+			if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+			if ctx.Err() != nil {
+				t.Error("SubscribeSSE kept reconnecting")
+			}
+			if n := conns.Load(); n != tt.wantConns {
+				t.Errorf("got %d connections, want %d", n, tt.wantConns)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the client's internal counters.
+type Stats struct {
+	Requests           int64 `json:"requests"`
+	Errors             int64 `json:"errors"`
+	InFlight           int64 `json:"in_flight"`
+	CacheHits          int64 `json:"cache_hits"`
+	CacheMisses        int64 `json:"cache_misses"`
+	CacheRevalidations int64 `json:"cache_revalidations"`
+	ConnsNew           int64 `json:"conns_new"`
+	ConnsReused        int64 `json:"conns_reused"`
+	Retries            int64 `json:"retries"`
+	// Coalesced counts requests answered by sharing an identical request
+	// in flight, see WithRequestCoalescing.
+	Coalesced int64 `json:"coalesced"`
+	// MigrationNew and MigrationOld count the requests served by the new
+	// and the old base URL during a WithBaseURLMigration.
+	MigrationNew int64 `json:"migration_new"`
+	MigrationOld int64 `json:"migration_old"`
+	// LimiterTokens is the number of requests WithRateLimit lets through
+	// right away; it is negative while requests wait for the limiter.
+	LimiterTokens float64 `json:"limiter_tokens"`
+	// Latency percentiles cover the time to response headers of the last
+	// 1024 requests.
+	LatencyP50 time.Duration `json:"latency_p50"`
+	LatencyP90 time.Duration `json:"latency_p90"`
+	LatencyP99 time.Duration `json:"latency_p99"`
+}
+
+// CacheHitRate is the share of cacheable requests served from the cache,
+// including successful revalidations.
+func (s Stats) CacheHitRate() float64 {
+	total := s.CacheHits + s.CacheMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.CacheHits) / float64(total)
+}
+
+type clientStats struct {
+	requests           atomic.Int64
+	errors             atomic.Int64
+	inFlight           atomic.Int64
+	cacheHits          atomic.Int64
+	cacheMisses        atomic.Int64
+	cacheRevalidations atomic.Int64
+	connsNew           atomic.Int64
+	connsReused        atomic.Int64
+	retries            atomic.Int64
+	coalesced          atomic.Int64
+	migrationNew       atomic.Int64
+	migrationOld       atomic.Int64
+	latencies          latencyWindow
+
+	traceOnce sync.Once
+	trace     *httptrace.ClientTrace
+}
+
+const latencyWindowSize = 1024
+
+// latencyWindow keeps the most recent latency samples in a ring.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.samples) < latencyWindowSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencyWindowSize
+}
+
+// percentiles returns the nearest-rank percentiles ps of the window.
+func (w *latencyWindow) percentiles(ps ...float64) []time.Duration {
+	w.mu.Lock()
+	sorted := append([]time.Duration(nil), w.samples...)
+	w.mu.Unlock()
+
+	out := make([]time.Duration, len(ps))
+	if len(sorted) == 0 {
+		return out
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i, p := range ps {
+		rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		out[i] = sorted[rank]
+	}
+	return out
+}
+
+func (r *RestHttp) Stats() Stats {
+	s := r.stats
+	if s == nil {
+		return Stats{LimiterTokens: r.limiter.available()}
+	}
+	latency := s.latencies.percentiles(50, 90, 99)
+	return Stats{
+		Requests:           s.requests.Load(),
+		Errors:             s.errors.Load(),
+		InFlight:           s.inFlight.Load(),
+		CacheHits:          s.cacheHits.Load(),
+		CacheMisses:        s.cacheMisses.Load(),
+		CacheRevalidations: s.cacheRevalidations.Load(),
+		ConnsNew:           s.connsNew.Load(),
+		ConnsReused:        s.connsReused.Load(),
+		Retries:            s.retries.Load(),
+		Coalesced:          s.coalesced.Load(),
+		MigrationNew:       s.migrationNew.Load(),
+		MigrationOld:       s.migrationOld.Load(),
+		LimiterTokens:      r.limiter.available(),
+		LatencyP50:         latency[0],
+		LatencyP90:         latency[1],
+		LatencyP99:         latency[2],
+	}
+}
+
+// count applies fn to the client's counters, if the client has any.
+func (r *RestHttp) count(fn func(s *clientStats)) {
+	if r.stats != nil {
+		fn(r.stats)
+	}
+}
+
+// traceConns records whether requests reuse pooled connections.
+func (r *RestHttp) traceConns(req *http.Request) *http.Request {
+	if r.stats == nil {
+		return req
+	}
+	s := r.stats
+	s.traceOnce.Do(func() {
+		s.trace = &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				if info.Reused {
+					s.connsReused.Add(1)
+				} else {
+					s.connsNew.Add(1)
+				}
+			},
+		}
+	})
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), s.trace))
+}
+
+var (
+	expvarMu      sync.Mutex
+	expvarClients = make(map[string]*RestHttp)
+)
+
+// PublishExpvar exposes the client's Stats as the expvar variable name, and
+// thereby on /debug/vars when the expvar handler is served. Publishing can be
+// switched off again at runtime with UnpublishExpvar and the name reused
+// afterwards. Like expvar.Publish, it panics if name is already in use by
+// another variable or by another client that is still published.
+func (r *RestHttp) PublishExpvar(name string) {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	client, known := expvarClients[name]
+	if !known {
+		if expvar.Get(name) != nil {
+			panic(fmt.Sprintf("resthttp: expvar name %q is already in use", name))
+		}
+		expvar.Publish(name, expvar.Func(func() interface{} {
+			expvarMu.Lock()
+			client := expvarClients[name]
+			expvarMu.Unlock()
+			if client == nil {
+				return nil
+			}
+			return client.Stats()
+		}))
+	} else if client != nil && client != r {
+		panic(fmt.Sprintf("resthttp: expvar name %q is already published by another client", name))
+	}
+	expvarClients[name] = r
+}
+
+// UnpublishExpvar stops reporting stats under name. expvar variables cannot
+// be removed, so the variable reads as null until published again.
+func UnpublishExpvar(name string) {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if _, ok := expvarClients[name]; ok {
+		expvarClients[name] = nil
+	}
+}
+
+// StatsHandler serves the client's Stats as JSON, for mounting on an
+// internal debug mux.
+func (r *RestHttp) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(r.Stats())
+	})
+}
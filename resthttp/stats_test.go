@@ -0,0 +1,78 @@
+package resthttp
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+
+	first := NewRestHttp(srv.URL, WithRateLimit(100, 5))
+	second := NewRestHttp(srv.URL)
+	if _, err := first.GetRequest("items", "1", nil, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	first.PublishExpvar("resthttp_test_client")
+	defer UnpublishExpvar("resthttp_test_client")
+	first.PublishExpvar("resthttp_test_client")
+	var stats Stats
+	if err := json.Unmarshal([]byte(expvar.Get("resthttp_test_client").String()), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Requests != 1 || stats.LimiterTokens < 3.9 || stats.LimiterTokens > 5 {
+		t.Errorf("published %+v, want 1 request and about 4 limiter tokens", stats)
+	}
+
+	expectPanic(t, "another client", func() { second.PublishExpvar("resthttp_test_client") })
+	if expvar.Get("resthttp_test_taken") == nil {
+		expvar.NewInt("resthttp_test_taken")
+	}
+	expectPanic(t, "a foreign variable", func() { first.PublishExpvar("resthttp_test_taken") })
+
+	UnpublishExpvar("resthttp_test_client")
+	if got := expvar.Get("resthttp_test_client").String(); got != "null" {
+		t.Errorf("unpublished variable reads %s, want null", got)
+	}
+	second.PublishExpvar("resthttp_test_client")
+}
+
+func expectPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: no panic", name)
+		}
+	}()
+	fn()
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+
+	r := NewRestHttp(srv.URL, WithRateLimit(50, 2))
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := r.GetRequest("items", "1", nil, "", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The burst covers two requests, the other two wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("4 requests took %s, want them limited to 50 per second", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.NewRequest().Container("items").Get(ctx); err == nil {
+		t.Error("request with a canceled context passed the limiter")
+	}
+}
@@ -0,0 +1,158 @@
+package resthttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+)
+
+// GetStream reads an NDJSON / JSON Lines (or any concatenated JSON) response
+// incrementally and calls fn for every value, so exports larger than memory
+// can be processed. Returning an error from fn stops the stream and is
+// returned as is.
+func (r *RestHttp) GetStream(ctx context.Context, container string, resource string, fn func(json.RawMessage) error, opts ...RequestOption) error {
+	ro := newRequestOptions(opts)
+	ro.stream = true
+
+	url := r.MakeURL(container, resource, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/x-ndjson, application/jsonl, application/json")
+
+	if err := r.prepareRequest(req, ro); err != nil {
+		return err
+	}
+
+	resp, err := r.do(req, ro)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if r.debugEnabled() {
+		r.printRequest("GET", resp.Request.URL.String(), req.Header, nil)
+	}
+
+	if !ro.acceptsStatus(resp.StatusCode) {
+		return r.responseError(resp)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var item json.RawMessage
+		if err := decoder.Decode(&item); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+}
+
+// DownloadTo writes the resource to w as it arrives, for destinations that
+// are not files such as pipes, object store writers or buffers. It returns
+// the number of bytes written. Download checks are not run, since they
+// inspect a saved file.
+func (r *RestHttp) DownloadTo(ctx context.Context, container string, resource string, w io.Writer, opts ...RequestOption) (int64, error) {
+	ro := newRequestOptions(opts)
+	ro.stream = true
+
+	url := r.MakeURL(container, resource, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("Accept-Encoding", r.acceptEncoding())
+	ro.keepEncoded = true
+
+	if err := r.prepareRequest(req, ro); err != nil {
+		return 0, err
+	}
+
+	resp, err := r.do(req, ro)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := r.handleResponse(resp, ro); err != nil {
+		return 0, err
+	}
+
+	if !isCompressedArtifact(resp, resource) {
+		if err := r.decodeResponse(resp); err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+	}
+
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return n, fmt.Errorf("could not download file: %s", err)
+	}
+
+	r.log(LogDebug, "downloaded file", "bytes", n, "url", resp.Request.URL.String())
+
+	return n, nil
+}
+
+// UploadFrom sends the content of body as the request body of a POST to the
+// resource and returns the response body. size is the content length, or -1
+// if unknown, in which case the body is sent chunked. The reader is consumed
+// once, so the request is not retried.
+func (r *RestHttp) UploadFrom(ctx context.Context, container string, resource string, body io.Reader, size int64, contentType string, opts ...RequestOption) ([]byte, error) {
+	ro := newRequestOptions(opts)
+	ro.stream = true
+
+	body, err := r.checkStream(path.Base(resource), size, body)
+	if err != nil {
+		return nil, err
+	}
+
+	url := r.MakeURL(container, resource, nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, ioutil.NopCloser(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	if err := r.prepareRequest(req, ro); err != nil {
+		return nil, err
+	}
+
+	resp, err := r.do(req, ro)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if r.debugEnabled() {
+		r.printRequest("POST", resp.Request.URL.String(), req.Header, nil)
+	}
+
+	if err := r.handleResponse(resp, ro); err != nil {
+		return nil, err
+	}
+
+	return r.readBody(resp, ro)
+}
@@ -0,0 +1,285 @@
+package resthttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WireTap mirrors the raw bytes exchanged for a sample of requests to W,
+// without running tcpdump on the host. Bytes are captured above TLS, so the
+// record is readable; credential headers are redacted like in log output.
+//
+// Tapped requests are sent on a connection of their own, over HTTP/1.1, so
+// that the captured bytes belong to that request alone. Clients using
+// WithTransport are not tapped.
+type WireTap struct {
+	W io.Writer
+	// SampleRate is the share of requests tapped, from 0 to 1.
+	SampleRate float64
+	// Sample, when set, decides instead of SampleRate.
+	Sample func(req *http.Request) bool
+	// MaxBytes bounds the bytes recorded per direction and connection,
+	// 64KB by default.
+	MaxBytes int
+
+	mu   sync.Mutex
+	seq  atomic.Int64
+	rand *rand.Rand
+}
+
+func WithWireTap(tap *WireTap) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.tap = tap
+	}
+}
+
+func (t *WireTap) sampled(req *http.Request) bool {
+	if t.Sample != nil {
+		return t.Sample(req)
+	}
+	if t.SampleRate >= 1 {
+		return true
+	}
+	if t.SampleRate <= 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rand == nil {
+		t.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return t.rand.Float64() < t.SampleRate
+}
+
+func (t *WireTap) maxBytes() int {
+	if t.MaxBytes <= 0 {
+		return 64 << 10
+	}
+	return t.MaxBytes
+}
+
+// tapTransport returns the transport for req when it is to be tapped.
+func (r *RestHttp) tapTransport(req *http.Request) http.RoundTripper {
+	t := r.tap
+	if t == nil || t.W == nil || r.transport != nil || !t.sampled(req) {
+		return nil
+	}
+	id := t.seq.Add(1)
+
+	tlsConfig := r.clientTLSConfig()
+	tlsConfig.NextProtos = []string{"http/1.1"}
+	dial := dialer.DialContext
+	if r.dns != nil {
+		dial = r.dns.dial
+	}
+	wrap := func(conn net.Conn, addr string) net.Conn {
+		return &tapConn{
+			Conn:   conn,
+			tap:    t,
+			header: fmt.Sprintf("=== wire tap #%d %s %s via %s ===\n", id, req.Method, r.redactedURL(req.URL), addr),
+			sent:   newTapBuffer(t.maxBytes(), r.redactedHeaderName, r.redactedRequestLine),
+			recv:   newTapBuffer(t.maxBytes(), r.redactedHeaderName, nil),
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if r.transportSettings != nil {
+		r.transportSettings.apply(transport)
+	}
+	if r.proxy != nil {
+		transport.Proxy = http.ProxyURL(r.proxy)
+	}
+	transport.DisableKeepAlives = true
+	transport.ForceAttemptHTTP2 = false
+	transport.TLSClientConfig = tlsConfig
+	transport.DialContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return wrap(conn, addr), nil
+	}
+	transport.DialTLSContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		raw, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		config := tlsConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		conn := tls.Client(raw, config)
+		if err := conn.HandshakeContext(ctx); err != nil {
+			raw.Close()
+			return nil, err
+		}
+		return wrap(conn, addr), nil
+	}
+	return transport
+}
+
+func (r *RestHttp) redactedHeaderName(name string) bool {
+	name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+	for _, redacted := range defaultRedactedHeaders {
+		if name == redacted {
+			return true
+		}
+	}
+	for _, redacted := range r.redactHeaders {
+		if name == redacted {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedRequestLine masks the credential query parameters in the target
+// of a request line such as "GET /items?token=x HTTP/1.1".
+func (r *RestHttp) redactedRequestLine(line string) string {
+	method, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return line
+	}
+	target, proto, ok := strings.Cut(rest, " ")
+	if !ok || !strings.Contains(target, "?") {
+		return line
+	}
+	return method + " " + r.redactedURLString(target) + " " + proto
+}
+
+// tapConn records the bytes passing through a connection and writes them to
+// the tap once the connection is closed.
+type tapConn struct {
+	net.Conn
+	tap    *WireTap
+	header string
+	sent   *tapBuffer
+	recv   *tapBuffer
+	once   sync.Once
+}
+
+func (c *tapConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.recv.write(p[:n])
+	return n, err
+}
+
+func (c *tapConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.sent.write(p[:n])
+	return n, err
+}
+
+func (c *tapConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		var out bytes.Buffer
+		out.WriteString(c.header)
+		c.sent.render(&out, ">>> sent")
+		c.recv.render(&out, "<<< received")
+		out.WriteString("\n")
+
+		c.tap.mu.Lock()
+		defer c.tap.mu.Unlock()
+		c.tap.W.Write(out.Bytes())
+	})
+	return err
+}
+
+// tapBuffer keeps up to limit bytes of one direction of a connection,
+// redacting header lines of each message head it sees. redactFirst, when
+// set, rewrites the first line of the head.
+type tapBuffer struct {
+	mu          sync.Mutex
+	limit       int
+	buf         bytes.Buffer
+	total       int
+	dropped     int
+	inHead      bool
+	first       bool
+	line        []byte
+	redact      func(name string) bool
+	redactFirst func(line string) string
+}
+
+func newTapBuffer(limit int, redact func(string) bool, redactFirst func(string) string) *tapBuffer {
+	return &tapBuffer{limit: limit, inHead: true, first: true, redact: redact, redactFirst: redactFirst}
+}
+
+func (b *tapBuffer) write(p []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.total += len(p)
+
+	for len(p) > 0 {
+		if !b.inHead {
+			b.keep(p)
+			return
+		}
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			b.line = append(b.line, p...)
+			return
+		}
+		b.line = append(b.line, p[:i+1]...)
+		p = p[i+1:]
+		b.endLine()
+	}
+}
+
+// endLine emits a complete head line, redacting it when needed.
+func (b *tapBuffer) endLine() {
+	line := b.line
+	b.line = nil
+	if len(bytes.TrimRight(line, "\r\n")) == 0 {
+		b.inHead = false
+		b.keep(line)
+		return
+	}
+	if b.first {
+		b.first = false
+		if b.redactFirst != nil {
+			text := strings.TrimRight(string(line), "\r\n")
+			line = []byte(b.redactFirst(text) + string(line[len(text):]))
+		}
+		b.keep(line)
+		return
+	}
+	if name, _, ok := strings.Cut(string(line), ":"); ok && b.redact(name) {
+		line = []byte(name + ": " + redacted + "\r\n")
+	}
+	b.keep(line)
+}
+
+func (b *tapBuffer) keep(p []byte) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+		b.buf.Write(p[:room])
+		p = p[room:]
+	}
+	b.dropped += len(p)
+}
+
+func (b *tapBuffer) render(out *bytes.Buffer, title string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fmt.Fprintf(out, "%s (%d bytes)\n", title, b.total)
+	out.Write(b.buf.Bytes())
+	if b.dropped > 0 {
+		fmt.Fprintf(out, "\n... %d bytes not recorded\n", b.dropped)
+	} else if b.buf.Len() > 0 && !bytes.HasSuffix(b.buf.Bytes(), []byte("\n")) {
+		out.WriteString("\n")
+	}
+}
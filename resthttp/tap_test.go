@@ -0,0 +1,58 @@
+package resthttp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the tap writing from the transport.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWireTapRedactsQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+
+	var out syncBuffer
+	r := NewRestHttp(srv.URL,
+		WithWireTap(&WireTap{W: &out, SampleRate: 1}),
+		WithAPIKey("api_key", "s3cret", APIKeyInQuery),
+		WithRedactedQueryParams("sig"))
+	if _, err := r.GetRequest("items", "1", url.Values{"sig": {"xyz123"}, "page": {"2"}}, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(out.String(), "<<< received") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	capture := out.String()
+	if !strings.Contains(capture, "GET /items/1?") || !strings.Contains(capture, "page=2") {
+		t.Fatalf("capture has no request line:\n%s", capture)
+	}
+	for _, secret := range []string{"s3cret", "xyz123"} {
+		if strings.Contains(capture, secret) {
+			t.Errorf("capture contains %q:\n%s", secret, capture)
+		}
+	}
+}
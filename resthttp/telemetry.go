@@ -0,0 +1,140 @@
+package resthttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// TelemetryConfig configures ReportTelemetry.
+type TelemetryConfig struct {
+	// Endpoint receives the reports. A path is resolved against the
+	// client's base URL; absolute URLs are used as is.
+	Endpoint string
+	// Interval between snapshots, one minute by default.
+	Interval time.Duration
+	// BatchSize is how many snapshots are sent per POST, 1 by default.
+	BatchSize int
+	// MaxPending caps snapshots kept while the endpoint is failing; the
+	// oldest are dropped first. Defaults to 100.
+	MaxPending int
+	// MaxBackoff bounds the pause after failed posts, 10 minutes by default.
+	MaxBackoff time.Duration
+	// Client and Labels identify the reporting client.
+	Client string
+	Labels map[string]string
+	// Options are applied to every telemetry request.
+	Options []RequestOption
+}
+
+// TelemetryReport is one snapshot of the client's Stats.
+type TelemetryReport struct {
+	Time   time.Time         `json:"time"`
+	Client string            `json:"client,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Stats  Stats             `json:"stats"`
+}
+
+// ReportTelemetry snapshots the client's Stats every interval and POSTs them
+// in batches, as {"reports": [...]}, to the configured endpoint using the
+// client itself until ctx is done. Failed posts are retried with exponential
+// backoff; whatever is still pending when ctx ends is sent one last time.
+// The telemetry requests are counted in the client's own Stats.
+func (r *RestHttp) ReportTelemetry(ctx context.Context, cfg TelemetryConfig) error {
+	if cfg.Endpoint == "" {
+		return errors.New("telemetry endpoint is required")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = 100
+	}
+	if cfg.MaxPending < cfg.BatchSize {
+		cfg.MaxPending = cfg.BatchSize
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+
+		var pending []TelemetryReport
+		var backoff time.Duration
+		var retryAt time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				if len(pending) > 0 {
+					flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					r.postTelemetry(flushCtx, cfg, pending)
+					cancel()
+				}
+				return
+			case now := <-ticker.C:
+				pending = append(pending, TelemetryReport{
+					Time:   now,
+					Client: cfg.Client,
+					Labels: cfg.Labels,
+					Stats:  r.Stats(),
+				})
+				if len(pending) > cfg.MaxPending {
+					pending = pending[len(pending)-cfg.MaxPending:]
+				}
+				if len(pending) < cfg.BatchSize || now.Before(retryAt) {
+					continue
+				}
+
+				if err := r.postTelemetry(ctx, cfg, pending); err != nil {
+					if backoff == 0 {
+						backoff = cfg.Interval
+					} else if backoff *= 2; backoff > cfg.MaxBackoff {
+						backoff = cfg.MaxBackoff
+					}
+					retryAt = now.Add(backoff)
+					r.log(LogError, "telemetry report failed", "error", err, "retry_in", backoff)
+					continue
+				}
+				pending = nil
+				backoff = 0
+				retryAt = time.Time{}
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *RestHttp) postTelemetry(ctx context.Context, cfg TelemetryConfig, reports []TelemetryReport) error {
+	payload, err := json.Marshal(struct {
+		Reports []TelemetryReport `json:"reports"`
+	}{reports})
+	if err != nil {
+		return err
+	}
+
+	url := cfg.Endpoint
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = r.baseURL() + "/" + strings.TrimLeft(url, "/")
+	}
+	opts := append([]RequestOption{
+		WithRequestHeader("Content-Type", "application/json"),
+		WithNoRetry(),
+	}, cfg.Options...)
+
+	resp, err := r.execute(ctx, "POST", url, bytes.NewReader(payload), opts)
+	if err != nil {
+		return err
+	}
+	if resp.failed() {
+		return r.statusError(resp.StatusCode, resp.Status, resp.Header, resp.Body)
+	}
+	return nil
+}
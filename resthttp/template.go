@@ -0,0 +1,188 @@
+package resthttp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateFuncs returns the helpers available to body templates. Names and
+// argument order follow sprig so that piped values come last:
+//
+//	{"name": {{ .name | default "anonymous" | quote }}, "tags": {{ .tags | toJson }}}
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"default":      templateDefault,
+		"required":     templateRequired,
+		"empty":        isEmptyValue,
+		"coalesce":     templateCoalesce,
+		"upper":        strings.ToUpper,
+		"lower":        strings.ToLower,
+		"trim":         strings.TrimSpace,
+		"trimPrefix":   func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix":   func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":      func(old string, new string, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":     func(substr string, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":    func(prefix string, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":    func(suffix string, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":        func(sep string, s string) []string { return strings.Split(s, sep) },
+		"join":         templateJoin,
+		"indent":       templateIndent,
+		"quote":        func(v interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(v)) },
+		"squote":       func(v interface{}) string { return "'" + fmt.Sprint(v) + "'" },
+		"toString":     func(v interface{}) string { return fmt.Sprint(v) },
+		"toJson":       templateJSON,
+		"toPrettyJson": templatePrettyJSON,
+		"b64enc":       func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":       templateBase64Decode,
+		"env":          os.Getenv,
+		"now":          time.Now,
+		"date":         func(layout string, t time.Time) string { return t.Format(layout) },
+		"list":         func(items ...interface{}) []interface{} { return items },
+		"dict":         templateDict,
+		"add":          func(a int, b int) int { return a + b },
+		"sub":          func(a int, b int) int { return a - b },
+	}
+}
+
+// WithTemplateFuncs adds or replaces helpers available to body templates.
+func WithTemplateFuncs(funcs template.FuncMap) func(*RestHttp) {
+	return func(r *RestHttp) {
+		if r.templateFuncs == nil {
+			r.templateFuncs = TemplateFuncs()
+		}
+		for name, fn := range funcs {
+			r.templateFuncs[name] = fn
+		}
+	}
+}
+
+// RenderTemplate executes a text/template with TemplateFuncs. Referencing a
+// key missing from a data map is an error.
+func RenderTemplate(text string, data interface{}) ([]byte, error) {
+	return renderTemplate(text, data, TemplateFuncs())
+}
+
+func renderTemplate(text string, data interface{}, funcs template.FuncMap) ([]byte, error) {
+	tmpl, err := template.New("body").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template: %s", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("could not render template: %s", err)
+	}
+	return out.Bytes(), nil
+}
+
+// Template renders the request body from a text/template.
+func (b *RequestBuilder) Template(text string, data interface{}, contentType string) *RequestBuilder {
+	funcs := b.client.templateFuncs
+	if funcs == nil {
+		funcs = TemplateFuncs()
+	}
+	body, err := renderTemplate(text, data, funcs)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	return b.Body(body, contentType)
+}
+
+// TemplateFile renders the request body from the template stored at path.
+func (b *RequestBuilder) TemplateFile(path string, data interface{}, contentType string) *RequestBuilder {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	return b.Template(string(text), data, contentType)
+}
+
+func isEmptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return value.IsZero()
+}
+
+func templateDefault(def interface{}, v ...interface{}) interface{} {
+	if len(v) == 0 || isEmptyValue(v[0]) {
+		return def
+	}
+	return v[0]
+}
+
+func templateRequired(msg string, v interface{}) (interface{}, error) {
+	if isEmptyValue(v) {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+func templateCoalesce(values ...interface{}) interface{} {
+	for _, v := range values {
+		if !isEmptyValue(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func templateJoin(sep string, v interface{}) string {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return fmt.Sprint(v)
+	}
+	parts := make([]string, value.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(value.Index(i).Interface())
+	}
+	return strings.Join(parts, sep)
+}
+
+func templateIndent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func templateJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func templatePrettyJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	return string(data), err
+}
+
+func templateBase64Decode(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	return string(data), err
+}
+
+func templateDict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict expects key/value pairs")
+	}
+	dict := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		dict[fmt.Sprint(pairs[i])] = pairs[i+1]
+	}
+	return dict, nil
+}
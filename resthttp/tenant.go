@@ -0,0 +1,146 @@
+package resthttp
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/cookiejar"
+	"sync"
+)
+
+// WithTenantIsolation gives every tenant its own connection pool and cookie
+// jar while sharing one client, so that sessions bound to a connection (NTLM,
+// mTLS, sticky cookies) never bleed from one tenant into another. The tenant
+// of a request is the one named by WithTenant, or else its credentials as
+// found in the Authorization header; anonymous requests share a pool.
+//
+// configure, when not nil, is called once for each new tenant's transport,
+// for example to install the tenant's client certificate.
+//
+// At most DefaultMaxTenants pools are kept, see WithMaxTenants; the least
+// recently used one is closed, along with its cookies, to make room. Keying
+// on credentials means a rotated token starts a new pool, so prefer
+// WithTenant when tokens change.
+func WithTenantIsolation(configure func(tenant string, transport *http.Transport)) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.tenants = &tenantPools{configure: configure, pools: make(map[string]*list.Element), lru: list.New()}
+	}
+}
+
+// DefaultMaxTenants is the number of tenant pools kept by default.
+const DefaultMaxTenants = 256
+
+// WithMaxTenants sets the number of tenant pools WithTenantIsolation keeps
+// before closing the least recently used one.
+func WithMaxTenants(n int) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.maxTenants = n
+	}
+}
+
+// WithTenant sends this request on behalf of tenant.
+func WithTenant(tenant string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.tenant = tenant
+	}
+}
+
+// CloseTenant closes the idle connections of tenant and forgets its pool and
+// cookies.
+func (r *RestHttp) CloseTenant(tenant string) {
+	if r.tenants == nil {
+		return
+	}
+	r.tenants.mu.Lock()
+	pool := r.tenants.remove(tenant)
+	r.tenants.mu.Unlock()
+	if pool != nil {
+		pool.transport.CloseIdleConnections()
+	}
+}
+
+type tenantPools struct {
+	configure func(tenant string, transport *http.Transport)
+	mu        sync.Mutex
+	pools     map[string]*list.Element
+	lru       *list.List
+}
+
+type tenantPool struct {
+	key       string
+	transport *http.Transport
+	jar       http.CookieJar
+}
+
+func (t *tenantPools) remove(key string) *tenantPool {
+	elem, ok := t.pools[key]
+	if !ok {
+		return nil
+	}
+	t.lru.Remove(elem)
+	delete(t.pools, key)
+	return elem.Value.(*tenantPool)
+}
+
+// tenantKey derives the pool a request belongs to.
+func tenantKey(req *http.Request, ro *requestOptions) string {
+	if ro.tenant != "" {
+		return ro.tenant
+	}
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		sum := sha256.Sum256([]byte(auth))
+		return "credential:" + hex.EncodeToString(sum[:8])
+	}
+	return ""
+}
+
+// tenantClient switches client to the pool of the request's tenant. Clients
+// with a custom RoundTripper other than *http.Transport are left alone.
+func (r *RestHttp) tenantClient(client *http.Client, req *http.Request, ro *requestOptions) {
+	if r.tenants == nil {
+		return
+	}
+	base, ok := client.Transport.(*http.Transport)
+	if client.Transport == nil {
+		base, ok = http.DefaultTransport.(*http.Transport), true
+	}
+	if !ok {
+		return
+	}
+
+	max := r.maxTenants
+	if max <= 0 {
+		max = DefaultMaxTenants
+	}
+
+	key := tenantKey(req, ro)
+	t := r.tenants
+	t.mu.Lock()
+	var pool *tenantPool
+	var evicted []*tenantPool
+	if elem, ok := t.pools[key]; ok {
+		t.lru.MoveToFront(elem)
+		pool = elem.Value.(*tenantPool)
+	} else {
+		transport := base.Clone()
+		if t.configure != nil {
+			t.configure(key, transport)
+		}
+		jar, _ := cookiejar.New(nil)
+		pool = &tenantPool{key: key, transport: transport, jar: jar}
+		t.pools[key] = t.lru.PushFront(pool)
+		for t.lru.Len() > max {
+			evicted = append(evicted, t.remove(t.lru.Back().Value.(*tenantPool).key))
+		}
+	}
+	t.mu.Unlock()
+
+	// Requests still running on an evicted pool keep their connections;
+	// only idle ones are closed.
+	for _, old := range evicted {
+		old.transport.CloseIdleConnections()
+	}
+	client.Transport = pool.transport
+	client.Jar = pool.jar
+}
@@ -0,0 +1,96 @@
+package resthttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrHeaderTimeout is returned, wrapped, when a server does not start its
+// response within the response header timeout.
+var ErrHeaderTimeout = errors.New("timeout awaiting response headers")
+
+// WithTimeout bounds this request, including retries and reading the
+// response body, replacing the client's Timeout. It is implemented as a
+// context deadline.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(ro *requestOptions) {
+		ro.timeout = timeout
+	}
+}
+
+// WithResponseHeaderTimeout bounds the wait for the response headers of each
+// attempt without limiting how long the body takes to read. Streaming calls
+// such as GetStream and SubscribeSSE use the client's Timeout for this by
+// default, since their bodies are expected to stay open.
+func WithResponseHeaderTimeout(timeout time.Duration) RequestOption {
+	return func(ro *requestOptions) {
+		ro.headerTimeout = timeout
+	}
+}
+
+// withDeadline applies WithTimeout to req. The returned function finishes
+// the request: it keeps the deadline running until the response body is
+// closed.
+func withDeadline(req *http.Request, ro *requestOptions) (*http.Request, func(*http.Response, error) (*http.Response, error)) {
+	if ro.timeout <= 0 {
+		return req, func(resp *http.Response, err error) (*http.Response, error) { return resp, err }
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), ro.timeout)
+	return req.WithContext(ctx), func(resp *http.Response, err error) (*http.Response, error) {
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+}
+
+// awaitHeaders applies the response header timeout to an attempt.
+func (r *RestHttp) awaitHeaders(req *http.Request, ro *requestOptions) (*http.Request, func(*http.Response, error) (*http.Response, error)) {
+	timeout := ro.headerTimeout
+	if timeout <= 0 && ro.stream {
+		timeout = r.timeout()
+	}
+	if timeout <= 0 {
+		return req, func(resp *http.Response, err error) (*http.Response, error) { return resp, err }
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	var expired atomic.Bool
+	timer := time.AfterFunc(timeout, func() {
+		expired.Store(true)
+		cancel()
+	})
+	return req.WithContext(ctx), func(resp *http.Response, err error) (*http.Response, error) {
+		timer.Stop()
+		if err != nil {
+			cancel()
+			if expired.Load() {
+				return nil, fmt.Errorf("%s %s: %w after %s", req.Method, req.URL, ErrHeaderTimeout, timeout)
+			}
+			return nil, err
+		}
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+}
+
+// cancelBody releases a request context once the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.cancel)
+	return err
+}
@@ -0,0 +1,232 @@
+package resthttp
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// TransportSettings tunes the shared transport's connection pool. Zero
+// fields keep the defaults of http.DefaultTransport. For high request rates
+// against a single host, raise MaxIdleConnsPerHost to about the expected
+// concurrency: its default of 2 makes most connections close after one
+// request, which can exhaust ephemeral ports.
+type TransportSettings struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	ExpectContinueTimeout time.Duration
+	DisableKeepAlives     bool
+}
+
+// WithTransportSettings applies settings to the client's transport. It has
+// no effect together with WithTransport.
+func WithTransportSettings(settings TransportSettings) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.transportSettings = &settings
+	}
+}
+
+func WithMaxIdleConnsPerHost(n int) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.tuneTransport().MaxIdleConnsPerHost = n
+	}
+}
+
+func WithMaxConnsPerHost(n int) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.tuneTransport().MaxConnsPerHost = n
+	}
+}
+
+func WithIdleConnTimeout(timeout time.Duration) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.tuneTransport().IdleConnTimeout = timeout
+	}
+}
+
+func WithTLSHandshakeTimeout(timeout time.Duration) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.tuneTransport().TLSHandshakeTimeout = timeout
+	}
+}
+
+// WithTransportResponseHeaderTimeout bounds the wait for response headers of
+// every request; WithResponseHeaderTimeout does the same for one request.
+func WithTransportResponseHeaderTimeout(timeout time.Duration) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.tuneTransport().ResponseHeaderTimeout = timeout
+	}
+}
+
+func WithExpectContinueTimeout(timeout time.Duration) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.tuneTransport().ExpectContinueTimeout = timeout
+	}
+}
+
+func WithDisableKeepAlives() func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.tuneTransport().DisableKeepAlives = true
+	}
+}
+
+// tuneTransport replaces the client's settings by a copy to be changed, like
+// dnsConfig.
+func (r *RestHttp) tuneTransport() *TransportSettings {
+	settings := &TransportSettings{}
+	if r.transportSettings != nil {
+		*settings = *r.transportSettings
+	}
+	r.transportSettings = settings
+	return settings
+}
+
+func (s *TransportSettings) apply(transport *http.Transport) {
+	if s.MaxIdleConns > 0 {
+		transport.MaxIdleConns = s.MaxIdleConns
+	}
+	if s.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = s.MaxIdleConnsPerHost
+		if transport.MaxIdleConns > 0 && transport.MaxIdleConns < s.MaxIdleConnsPerHost {
+			transport.MaxIdleConns = s.MaxIdleConnsPerHost
+		}
+	}
+	if s.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = s.MaxConnsPerHost
+	}
+	if s.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = s.IdleConnTimeout
+	}
+	if s.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = s.TLSHandshakeTimeout
+	}
+	if s.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = s.ResponseHeaderTimeout
+	}
+	if s.ExpectContinueTimeout > 0 {
+		transport.ExpectContinueTimeout = s.ExpectContinueTimeout
+	}
+	if s.DisableKeepAlives {
+		transport.DisableKeepAlives = true
+	}
+}
+
+// WithTLSConfig uses config, for example with custom root CAs or a client
+// certificate, for all connections. VerifySSL and WithTLSKeyLog still apply
+// on top of it.
+func WithTLSConfig(config *tls.Config) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.tlsConfig = config
+	}
+}
+
+// WithProxy sends all requests through the proxy at proxyURL instead of the
+// one named by the HTTP_PROXY and HTTPS_PROXY environment variables.
+func WithProxy(proxyURL string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		proxy, err := url.Parse(proxyURL)
+		if err != nil || proxy.Host == "" {
+			r.log(LogError, "ignoring invalid proxy URL", "proxy", redactURL(proxyURL))
+			return
+		}
+		r.proxy = proxy
+	}
+}
+
+func (r *RestHttp) clientTLSConfig() *tls.Config {
+	config := &tls.Config{}
+	if r.tlsConfig != nil {
+		config = r.tlsConfig.Clone()
+	}
+	if !r.VerifySSL {
+		config.InsecureSkipVerify = true
+	}
+	if r.keyLog != nil {
+		config.KeyLogWriter = r.keyLog
+	}
+	return config
+}
+
+// sharedTransport holds the transport built for a client and its clones.
+type sharedTransport struct {
+	mu        sync.Mutex
+	transport *http.Transport
+	verify    bool
+}
+
+// httpTransport returns the transport shared by all requests of the client,
+// building it on first use so that connections are pooled across calls. It
+// is rebuilt should VerifySSL change afterwards. nil means
+// http.DefaultTransport.
+func (r *RestHttp) httpTransport() http.RoundTripper {
+	if r.transport != nil {
+		return r.transport
+	}
+	if r.VerifySSL && r.keyLog == nil && r.dns == nil && r.transportSettings == nil && r.tlsConfig == nil && r.proxy == nil {
+		return nil
+	}
+
+	shared := r.transports
+	if shared == nil {
+		// A client not created by NewRestHttp.
+		shared = &sharedTransport{}
+	}
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+	if shared.transport == nil || shared.verify != r.VerifySSL {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = r.clientTLSConfig()
+		if r.proxy != nil {
+			transport.Proxy = http.ProxyURL(r.proxy)
+		}
+		if r.dns != nil {
+			transport.DialContext = r.dns.dial
+		}
+		if r.transportSettings != nil {
+			r.transportSettings.apply(transport)
+		}
+		if shared.transport != nil {
+			shared.transport.CloseIdleConnections()
+		}
+		shared.transport = transport
+		shared.verify = r.VerifySSL
+	}
+	return shared.transport
+}
+
+// maxBodyPrealloc bounds the buffer allocated up front from a response's
+// Content-Length, which a server may overstate.
+const maxBodyPrealloc = 4 << 20
+
+// readAll reads body into a buffer sized from the expected size, usually a
+// Content-Length, avoiding the repeated growth of ioutil.ReadAll.
+func readAll(body io.Reader, size int64) ([]byte, error) {
+	if size <= 0 || size > maxBodyPrealloc {
+		return ioutil.ReadAll(body)
+	}
+
+	// One spare byte lets the read that reports io.EOF land in the buffer.
+	buf := make([]byte, 0, size+1)
+	for {
+		n, err := body.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err == io.EOF {
+			return buf, nil
+		}
+		if err != nil {
+			return buf, err
+		}
+		if len(buf) == cap(buf) {
+			rest, err := ioutil.ReadAll(body)
+			return append(buf, rest...), err
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package resthttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UploadInfo describes a file about to be uploaded.
+type UploadInfo struct {
+	// Name is the file name sent to the server.
+	Name string
+	// Path is the local path of the file, empty for content that is not
+	// read from a file.
+	Path string
+	// Size is -1 when an UploadFrom reader has an unknown size.
+	Size int64
+}
+
+// UploadPolicy is enforced on every file before an upload request is built,
+// so rejected files never leave the machine.
+type UploadPolicy struct {
+	// MaxSize rejects files larger than this many bytes. Zero means no limit.
+	MaxSize int64
+	// AllowedExtensions, if set, lists the permitted extensions of the
+	// uploaded name (".pdf", ".png", ...), compared case-insensitively.
+	AllowedExtensions []string
+	// Validate is called with the file details for custom checks.
+	Validate func(info UploadInfo) error
+	// Scan receives the file content, e.g. to pass it through a virus
+	// scanner, and rejects the upload by returning an error.
+	Scan func(info UploadInfo, content io.Reader) error
+}
+
+// UploadRejectedError is returned when an UploadPolicy rejects a file.
+type UploadRejectedError struct {
+	Name   string
+	Reason string
+	Err    error
+}
+
+func (e *UploadRejectedError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("upload of %s rejected: %s: %s", e.Name, e.Reason, e.Err)
+	}
+	return fmt.Sprintf("upload of %s rejected: %s", e.Name, e.Reason)
+}
+
+func (e *UploadRejectedError) Unwrap() error {
+	return e.Err
+}
+
+func WithUploadPolicy(policy UploadPolicy) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.uploadPolicy = &policy
+	}
+}
+
+// checkUpload applies the upload policy to file, leaving its read offset
+// where it was.
+func (r *RestHttp) checkUpload(file *os.File, name string) error {
+	policy := r.uploadPolicy
+	if policy == nil {
+		return nil
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	info := UploadInfo{Name: name, Path: file.Name(), Size: stat.Size()}
+	if err := policy.check(info); err != nil {
+		return err
+	}
+
+	if policy.Scan != nil {
+		offset, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		scanErr := policy.Scan(info, file)
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		if scanErr != nil {
+			return &UploadRejectedError{Name: name, Reason: "scan failed", Err: scanErr}
+		}
+	}
+
+	return nil
+}
+
+// checkStream applies the upload policy to content that is sent while it
+// is read. Such content cannot be scanned beforehand, so a policy with a
+// Scan function rejects it. The returned reader enforces MaxSize as it is
+// read, for readers of unknown size or a wrong one.
+func (r *RestHttp) checkStream(name string, size int64, body io.Reader) (io.Reader, error) {
+	policy := r.uploadPolicy
+	if policy == nil {
+		return body, nil
+	}
+	if err := policy.check(UploadInfo{Name: name, Size: size}); err != nil {
+		return nil, err
+	}
+	if policy.Scan != nil {
+		return nil, &UploadRejectedError{Name: name, Reason: "streamed content cannot be scanned"}
+	}
+	if policy.MaxSize > 0 {
+		body = &sizeLimitedReader{r: body, name: name, limit: policy.MaxSize, remaining: policy.MaxSize}
+	}
+	return body, nil
+}
+
+// sizeLimitedReader fails with an UploadRejectedError once more than the
+// limit has been read.
+type sizeLimitedReader struct {
+	r         io.Reader
+	name      string
+	limit     int64
+	remaining int64
+}
+
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	// Read one byte past the limit to tell an exact fit from an overflow.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		return 0, &UploadRejectedError{Name: l.name, Reason: fmt.Sprintf("size exceeds limit of %d bytes", l.limit)}
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// checkContent applies the upload policy to content held in memory.
+func (r *RestHttp) checkContent(name string, data []byte) error {
+	policy := r.uploadPolicy
+	if policy == nil {
+		return nil
+	}
+	info := UploadInfo{Name: name, Size: int64(len(data))}
+	if err := policy.check(info); err != nil {
+		return err
+	}
+	if policy.Scan != nil {
+		if err := policy.Scan(info, bytes.NewReader(data)); err != nil {
+			return &UploadRejectedError{Name: name, Reason: "scan failed", Err: err}
+		}
+	}
+	return nil
+}
+
+// check applies the size, extension and custom checks to info.
+func (policy *UploadPolicy) check(info UploadInfo) error {
+	name := info.Name
+	if policy.MaxSize > 0 && info.Size > policy.MaxSize {
+		return &UploadRejectedError{Name: name, Reason: fmt.Sprintf("size %d exceeds limit of %d bytes", info.Size, policy.MaxSize)}
+	}
+
+	if len(policy.AllowedExtensions) > 0 {
+		ext := strings.ToLower(filepath.Ext(name))
+		allowed := false
+		for _, candidate := range policy.AllowedExtensions {
+			if strings.ToLower(candidate) == ext || strings.ToLower("."+strings.TrimPrefix(candidate, ".")) == ext {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return &UploadRejectedError{Name: name, Reason: fmt.Sprintf("extension %q is not allowed", ext)}
+		}
+	}
+
+	if policy.Validate != nil {
+		if err := policy.Validate(info); err != nil {
+			return &UploadRejectedError{Name: name, Reason: "validation failed", Err: err}
+		}
+	}
+	return nil
+}
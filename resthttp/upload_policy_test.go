@@ -0,0 +1,43 @@
+package resthttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUploadFromMaxSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.Copy(ioutil.Discard, req.Body)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		content  string
+		size     int64
+		rejected bool
+	}{
+		{"unknown size within limit", "12345678", -1, false},
+		{"unknown size over limit", "123456789", -1, true},
+		{"understated size", "123456789", 4, true},
+		{"announced size over limit", "123456789", 9, true},
+	}
+	r := NewRestHttp(srv.URL, WithUploadPolicy(UploadPolicy{MaxSize: 8}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Hide the length from net/http so the body is really streamed.
+			body := struct{ io.Reader }{strings.NewReader(tt.content)}
+			_, err := r.UploadFrom(context.Background(), "files", "a.bin", body, tt.size, "")
+			var rejected *UploadRejectedError
+			if got := errors.As(err, &rejected); got != tt.rejected {
+				t.Errorf("rejected = %v (err %v), want %v", got, err, tt.rejected)
+			}
+		})
+	}
+}
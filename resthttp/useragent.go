@@ -0,0 +1,45 @@
+package resthttp
+
+import (
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// Version is the version of this package, as announced in the default
+// User-Agent.
+const Version = "1.0"
+
+// DefaultUserAgent identifies the client when no User-Agent is configured,
+// for example "resthttp/1.0 go1.20.5".
+var DefaultUserAgent = "resthttp/" + Version + " " + runtime.Version()
+
+// WithUserAgent replaces DefaultUserAgent for all requests of the client. A
+// User-Agent in BaseHeaders or set per request takes precedence.
+func WithUserAgent(ua string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.userAgent = ua
+	}
+}
+
+// WithUserAgentProduct appends product tokens such as "my-tool/2.3" to the
+// User-Agent of this request.
+func WithUserAgentProduct(tokens ...string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.uaProducts = append(ro.uaProducts, tokens...)
+	}
+}
+
+func (r *RestHttp) setUserAgent(req *http.Request, ro *requestOptions) {
+	ua := req.Header.Get("User-Agent")
+	if ua == "" {
+		ua = r.userAgent
+		if ua == "" {
+			ua = DefaultUserAgent
+		}
+	}
+	if len(ro.uaProducts) > 0 {
+		ua = strings.TrimSpace(ua + " " + strings.Join(ro.uaProducts, " "))
+	}
+	req.Header.Set("User-Agent", ua)
+}
@@ -0,0 +1,214 @@
+package resthttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CheckStatus is the outcome of one Validate check.
+type CheckStatus string
+
+const (
+	CheckPassed  CheckStatus = "passed"
+	CheckWarning CheckStatus = "warning"
+	CheckFailed  CheckStatus = "failed"
+	CheckSkipped CheckStatus = "skipped"
+)
+
+// MaxClockSkew is the difference between local and server time above which
+// Validate reports the clock as skewed.
+const MaxClockSkew = time.Minute
+
+// CheckResult reports one aspect of the configuration.
+type CheckResult struct {
+	Name     string        `json:"name"`
+	Status   CheckStatus   `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Err      error         `json:"-"`
+}
+
+// ValidationReport is the result of Validate.
+type ValidationReport struct {
+	BaseURL string        `json:"base_url"`
+	Checks  []CheckResult `json:"checks"`
+	// ClockSkew is the server's clock minus the local clock, when the
+	// server sent a Date header.
+	ClockSkew time.Duration `json:"clock_skew"`
+}
+
+// OK reports whether no check failed; warnings are tolerated.
+func (rep *ValidationReport) OK() bool {
+	return rep.Err() == nil
+}
+
+// Err joins the errors of the failed checks.
+func (rep *ValidationReport) Err() error {
+	var errs []error
+	for _, check := range rep.Checks {
+		if check.Status == CheckFailed {
+			errs = append(errs, fmt.Errorf("%s: %s", check.Name, check.Detail))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (rep *ValidationReport) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "configuration check for %s\n", rep.BaseURL)
+	for _, check := range rep.Checks {
+		fmt.Fprintf(&out, "  %-8s %-12s %s\n", check.Status, check.Name, check.Detail)
+	}
+	return out.String()
+}
+
+// WithAuthProbe names a path that requires authentication and is cheap to
+// GET; Validate uses it to check the client's credentials.
+func WithAuthProbe(path string) func(*RestHttp) {
+	return func(r *RestHttp) {
+		r.authProbe = path
+	}
+}
+
+// Validate checks the client's configuration against the live service: that
+// the base URL is well formed and reachable, that the server certificate is
+// trusted, that the credentials are accepted by the WithAuthProbe endpoint,
+// and that the local clock agrees with the server's Date header. Problems
+// are reported in the returned report rather than as an error.
+func (r *RestHttp) Validate(ctx context.Context) *ValidationReport {
+	base := r.baseURL()
+	rep := &ValidationReport{BaseURL: base}
+	add := func(name string, start time.Time, status CheckStatus, err error, detail string, args ...interface{}) {
+		rep.Checks = append(rep.Checks, CheckResult{
+			Name:     name,
+			Status:   status,
+			Detail:   fmt.Sprintf(detail, args...),
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}
+
+	start := time.Now()
+	parsed, err := url.Parse(base)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		if err == nil {
+			err = errors.New("base URL must be an absolute http or https URL")
+		}
+		add("base_url", start, CheckFailed, err, "%s", err)
+		return rep
+	}
+	add("base_url", start, CheckPassed, nil, "%s", base)
+
+	start = time.Now()
+	resp, err := r.execute(ctx, "HEAD", base+"/", nil, []RequestOption{WithNoRetry(), WithNoAuth()})
+	received := time.Now()
+	tlsErr := certificateError(err)
+	switch {
+	case tlsErr != nil:
+		add("reachable", start, CheckPassed, nil, "connected to %s", parsed.Host)
+	case err != nil:
+		add("reachable", start, CheckFailed, err, "%s", err)
+	default:
+		add("reachable", start, CheckPassed, nil, "%s answered %s", parsed.Host, resp.Status)
+	}
+
+	switch {
+	case parsed.Scheme != "https":
+		add("tls", start, CheckWarning, nil, "plain http, traffic is not encrypted")
+	case tlsErr != nil:
+		add("tls", start, CheckFailed, tlsErr, "%s", tlsErr)
+	case !r.VerifySSL:
+		add("tls", start, CheckWarning, nil, "certificate verification is disabled")
+	case err != nil:
+		add("tls", start, CheckSkipped, nil, "no response from server")
+	default:
+		add("tls", start, CheckPassed, nil, "certificate trusted")
+	}
+
+	switch {
+	case err != nil:
+		add("clock_skew", received, CheckSkipped, nil, "no response from server")
+	default:
+		if skew, ok := clockSkew(resp.Header, start, received); !ok {
+			add("clock_skew", received, CheckSkipped, nil, "server sent no Date header")
+		} else {
+			rep.ClockSkew = skew
+			if skew > MaxClockSkew || skew < -MaxClockSkew {
+				add("clock_skew", received, CheckFailed, nil, "local clock is off by %s", -skew)
+			} else {
+				add("clock_skew", received, CheckPassed, nil, "within %s", MaxClockSkew)
+			}
+		}
+	}
+
+	start = time.Now()
+	if r.authProbe == "" {
+		add("auth", start, CheckSkipped, nil, "no auth probe configured")
+		return rep
+	}
+	if err != nil {
+		add("auth", start, CheckSkipped, nil, "no response from server")
+		return rep
+	}
+	probe, err := r.execute(ctx, "GET", base+"/"+strings.TrimLeft(r.authProbe, "/"), nil, []RequestOption{WithNoRetry()})
+	switch {
+	case err != nil:
+		add("auth", start, CheckFailed, err, "%s", err)
+	case probe.StatusCode == http.StatusUnauthorized || probe.StatusCode == http.StatusForbidden:
+		add("auth", start, CheckFailed, nil, "%s rejected the credentials: %s", r.authProbe, probe.Status)
+	case !probe.IsSuccess():
+		add("auth", start, CheckFailed, nil, "%s answered %s", r.authProbe, probe.Status)
+	default:
+		add("auth", start, CheckPassed, nil, "%s accepted the credentials", r.authProbe)
+	}
+	return rep
+}
+
+// clockSkew estimates the server clock minus the local clock from the Date
+// header of a response to a request sent at sent and answered at received.
+// Date has a resolution of one second.
+func clockSkew(header http.Header, sent time.Time, received time.Time) (time.Duration, bool) {
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return 0, false
+	}
+	local := sent.Add(received.Sub(sent) / 2)
+	return date.Sub(local).Round(time.Second), true
+}
+
+// certificateError returns the TLS trust failure behind err, if any.
+func certificateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var unknown x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var verify *tls.CertificateVerificationError
+	var record tls.RecordHeaderError
+	switch {
+	case errors.As(err, &verify):
+		return verify
+	case errors.As(err, &unknown):
+		return unknown
+	case errors.As(err, &invalid):
+		return invalid
+	case errors.As(err, &hostname):
+		return hostname
+	case errors.As(err, &record):
+		return record
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && strings.Contains(opErr.Error(), "tls:") {
+		return opErr
+	}
+	return nil
+}
@@ -0,0 +1,682 @@
+package resthttp
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// decodeYAML decodes the YAML subset configuration files are written in into
+// v, honoring yaml struct tags and falling back to json tags: block and flow
+// mappings and sequences, plain, quoted and block scalars, and comments.
+// Anchors, aliases, tags and multiple documents are rejected; register a YAML
+// library with RegisterConfigDecoder for those.
+func decodeYAML(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("yaml: decode needs a non-nil pointer, got %T", v)
+	}
+	p, err := newYAMLParser(string(data))
+	if err != nil {
+		return err
+	}
+	if p.done() {
+		return nil
+	}
+	node, err := p.node(p.lines[p.pos].indent)
+	if err != nil {
+		return err
+	}
+	if !p.done() {
+		return p.errorf("unexpected content")
+	}
+	return node.assign(rv.Elem())
+}
+
+type yamlKind int
+
+const (
+	yamlScalar yamlKind = iota
+	yamlMapping
+	yamlSequence
+)
+
+type yamlNode struct {
+	kind   yamlKind
+	value  string
+	quoted bool
+	keys   []string
+	values []*yamlNode
+	line   int
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	raw    string // without indentation
+	text   string // without indentation and comments
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func newYAMLParser(src string) (*yamlParser, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("yaml: line %d: tabs cannot be used for indentation", i+1)
+		}
+		text := strings.TrimRight(stripYAMLComment(trimmed), " \t")
+		if i == 0 && text == "---" {
+			continue
+		}
+		if text == "---" || text == "..." {
+			return nil, fmt.Errorf("yaml: line %d: only a single document is supported", i+1)
+		}
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: len(raw) - len(trimmed), raw: trimmed, text: text})
+	}
+	return p, nil
+}
+
+// stripYAMLComment cuts a # comment that is not inside quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t[{,:-", rune(s[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// skipBlank moves past empty lines.
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+}
+
+func (p *yamlParser) done() bool {
+	p.skipBlank()
+	return p.pos >= len(p.lines)
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	num := 0
+	if p.pos < len(p.lines) {
+		num = p.lines[p.pos].num
+	} else if len(p.lines) > 0 {
+		num = p.lines[len(p.lines)-1].num
+	}
+	return fmt.Errorf("yaml: line %d: %s", num, fmt.Sprintf(format, args...))
+}
+
+// node parses the block starting at the current line, which is indented by
+// indent.
+func (p *yamlParser) node(indent int) (*yamlNode, error) {
+	p.skipBlank()
+	line := p.lines[p.pos]
+	switch {
+	case line.text == "-" || strings.HasPrefix(line.text, "- "):
+		return p.sequence(indent)
+	case yamlKeyEnd(line.text) >= 0:
+		return p.mapping(indent)
+	}
+	p.pos++
+	return yamlInline(line.text, line.num)
+}
+
+func (p *yamlParser) mapping(indent int) (*yamlNode, error) {
+	node := &yamlNode{kind: yamlMapping, line: p.lines[p.pos].num}
+	for !p.done() {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		end := yamlKeyEnd(line.text)
+		if end < 0 || line.text == "-" || strings.HasPrefix(line.text, "- ") {
+			return nil, p.errorf("expected a key: value pair")
+		}
+		key, err := yamlInline(strings.TrimSpace(line.text[:end]), line.num)
+		if err != nil {
+			return nil, err
+		}
+		if key.kind != yamlScalar {
+			return nil, p.errorf("only scalar keys are supported")
+		}
+		for _, k := range node.keys {
+			if k == key.value {
+				return nil, p.errorf("duplicate key %q", key.value)
+			}
+		}
+		rest := strings.TrimSpace(line.text[end+1:])
+		p.pos++
+
+		var value *yamlNode
+		switch {
+		case rest == "":
+			value, err = p.nested(indent, true)
+		case rest[0] == '|' || rest[0] == '>':
+			value, err = p.blockScalar(indent, rest, line.num)
+		default:
+			value, err = yamlInline(rest, line.num)
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.keys = append(node.keys, key.value)
+		node.values = append(node.values, value)
+	}
+	return node, nil
+}
+
+// nested parses the value of a key or sequence entry written on the lines
+// below it. Sequences may be indented like the key they belong to.
+func (p *yamlParser) nested(indent int, sameIndentSequence bool) (*yamlNode, error) {
+	if p.done() {
+		return &yamlNode{kind: yamlScalar}, nil
+	}
+	next := p.lines[p.pos]
+	if next.indent > indent {
+		return p.node(next.indent)
+	}
+	if sameIndentSequence && next.indent == indent && (next.text == "-" || strings.HasPrefix(next.text, "- ")) {
+		return p.sequence(indent)
+	}
+	return &yamlNode{kind: yamlScalar, line: next.num}, nil
+}
+
+func (p *yamlParser) sequence(indent int) (*yamlNode, error) {
+	node := &yamlNode{kind: yamlSequence, line: p.lines[p.pos].num}
+	for !p.done() {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		if line.text != "-" && !strings.HasPrefix(line.text, "- ") {
+			if yamlKeyEnd(line.text) >= 0 {
+				// The sequence was the value of a key at the same indent.
+				break
+			}
+			return nil, p.errorf("expected a sequence entry")
+		}
+
+		rest := strings.TrimLeft(line.text[1:], " ")
+		var value *yamlNode
+		var err error
+		switch {
+		case rest == "":
+			p.pos++
+			value, err = p.nested(indent, false)
+		case rest[0] == '|' || rest[0] == '>':
+			p.pos++
+			value, err = p.blockScalar(indent, rest, line.num)
+		case rest == "-" || strings.HasPrefix(rest, "- ") || yamlKeyEnd(rest) >= 0:
+			// An entry starting on the dash line, like "- name: a": parse
+			// it as a block indented to where its content starts.
+			offset := len(line.text) - len(rest)
+			p.lines[p.pos].indent += offset
+			p.lines[p.pos].text = rest
+			p.lines[p.pos].raw = strings.TrimLeft(line.raw[1:], " ")
+			value, err = p.node(indent + offset)
+		default:
+			p.pos++
+			value, err = yamlInline(rest, line.num)
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.values = append(node.values, value)
+	}
+	return node, nil
+}
+
+// blockScalar reads a literal (|) or folded (>) scalar whose lines are
+// indented deeper than indent.
+func (p *yamlParser) blockScalar(indent int, header string, num int) (*yamlNode, error) {
+	style, chomp := header[0], header[1:]
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, fmt.Errorf("yaml: line %d: unsupported block scalar header %q", num, header)
+	}
+
+	var lines []string
+	contentIndent := -1
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.raw == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if line.indent <= indent {
+			break
+		}
+		if contentIndent < 0 {
+			contentIndent = line.indent
+		}
+		if line.indent < contentIndent {
+			return nil, p.errorf("block scalar line is indented less than the first")
+		}
+		lines = append(lines, strings.Repeat(" ", line.indent-contentIndent)+line.raw)
+		p.pos++
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			// Folding turns line breaks into spaces and each empty line
+			// into a line break.
+			switch {
+			case style == '|' || line == "":
+				b.WriteByte('\n')
+			case lines[i-1] != "":
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(line)
+	}
+	value := b.String()
+	switch {
+	case len(lines) == 0:
+	case chomp == "":
+		value += "\n"
+	case chomp == "+":
+		value += strings.Repeat("\n", trailing+1)
+	}
+	return &yamlNode{kind: yamlScalar, value: value, quoted: true, line: num}, nil
+}
+
+// yamlKeyEnd returns the index of the colon ending a mapping key in s, or -1.
+func yamlKeyEnd(s string) int {
+	if s == "" || s[0] == '[' || s[0] == '{' {
+		return -1
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		end := yamlQuoteEnd(s)
+		if end < 0 || end+1 >= len(s) || s[end+1] != ':' {
+			return -1
+		}
+		if end+2 == len(s) || s[end+2] == ' ' {
+			return end + 1
+		}
+		return -1
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ') {
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlQuoteEnd returns the index of the quote closing the scalar s starts.
+func yamlQuoteEnd(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlInline parses a value written on one line: a flow collection, a quoted
+// or a plain scalar.
+func yamlInline(s string, num int) (*yamlNode, error) {
+	f := yamlFlow{s: s, num: num}
+	node, err := f.value(false)
+	if err != nil {
+		return nil, err
+	}
+	f.space()
+	if f.pos < len(f.s) {
+		return nil, f.errorf("unexpected %q", f.s[f.pos:])
+	}
+	return node, nil
+}
+
+type yamlFlow struct {
+	s   string
+	pos int
+	num int
+}
+
+func (f *yamlFlow) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", f.num, fmt.Sprintf(format, args...))
+}
+
+func (f *yamlFlow) space() {
+	for f.pos < len(f.s) && f.s[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+func (f *yamlFlow) value(inFlow bool) (*yamlNode, error) {
+	f.space()
+	if f.pos >= len(f.s) {
+		return &yamlNode{kind: yamlScalar, line: f.num}, nil
+	}
+	switch c := f.s[f.pos]; c {
+	case '[':
+		return f.collection(']')
+	case '{':
+		return f.collection('}')
+	case '"', '\'':
+		return f.quoted()
+	case '&', '*', '!':
+		return nil, f.errorf("anchors, aliases and tags are not supported")
+	case '|', '>':
+		return nil, f.errorf("block scalars must end their line")
+	case '@', '`':
+		return nil, f.errorf("%q cannot start a plain scalar", c)
+	}
+
+	start := f.pos
+	for f.pos < len(f.s) {
+		c := f.s[f.pos]
+		if inFlow && (c == ',' || c == ']' || c == '}') {
+			break
+		}
+		if inFlow && c == ':' && (f.pos+1 == len(f.s) || strings.ContainsRune(" ,]}", rune(f.s[f.pos+1]))) {
+			break
+		}
+		f.pos++
+	}
+	return &yamlNode{kind: yamlScalar, value: strings.TrimSpace(f.s[start:f.pos]), line: f.num}, nil
+}
+
+func (f *yamlFlow) quoted() (*yamlNode, error) {
+	rest := f.s[f.pos:]
+	end := yamlQuoteEnd(rest)
+	if end < 0 {
+		return nil, f.errorf("unterminated quoted scalar")
+	}
+	f.pos += end + 1
+	if rest[0] == '\'' {
+		return &yamlNode{kind: yamlScalar, value: strings.ReplaceAll(rest[1:end], "''", "'"), quoted: true, line: f.num}, nil
+	}
+	value, err := strconv.Unquote(rest[:end+1])
+	if err != nil {
+		return nil, f.errorf("invalid double-quoted scalar %s", rest[:end+1])
+	}
+	return &yamlNode{kind: yamlScalar, value: value, quoted: true, line: f.num}, nil
+}
+
+func (f *yamlFlow) collection(closing byte) (*yamlNode, error) {
+	node := &yamlNode{kind: yamlSequence, line: f.num}
+	if closing == '}' {
+		node.kind = yamlMapping
+	}
+	f.pos++
+	for {
+		f.space()
+		if f.pos < len(f.s) && f.s[f.pos] == closing {
+			f.pos++
+			return node, nil
+		}
+		item, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		f.space()
+		if node.kind == yamlMapping {
+			if item.kind != yamlScalar {
+				return nil, f.errorf("only scalar keys are supported")
+			}
+			value := &yamlNode{kind: yamlScalar, line: f.num}
+			if f.pos < len(f.s) && f.s[f.pos] == ':' {
+				f.pos++
+				if value, err = f.value(true); err != nil {
+					return nil, err
+				}
+				f.space()
+			}
+			node.keys = append(node.keys, item.value)
+			node.values = append(node.values, value)
+		} else {
+			node.values = append(node.values, item)
+		}
+
+		if f.pos >= len(f.s) {
+			return nil, f.errorf("unterminated flow collection")
+		}
+		switch f.s[f.pos] {
+		case ',':
+			f.pos++
+		case closing:
+		default:
+			return nil, f.errorf("expected , or %c", closing)
+		}
+	}
+}
+
+func (n *yamlNode) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", n.line, fmt.Sprintf(format, args...))
+}
+
+// isNull reports an empty or null plain scalar.
+func (n *yamlNode) isNull() bool {
+	if n.kind != yamlScalar || n.quoted {
+		return false
+	}
+	switch n.value {
+	case "", "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+// resolve returns the value of a plain scalar stored in an interface{}
+// under the YAML 1.2 core schema.
+func (n *yamlNode) resolve() interface{} {
+	if n.quoted {
+		return n.value
+	}
+	if n.isNull() {
+		return nil
+	}
+	if b, ok := yamlBool(n.value); ok {
+		return b
+	}
+	if i, err := strconv.ParseInt(n.value, 0, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(n.value, 64); err == nil {
+		return f
+	}
+	return n.value
+}
+
+func yamlBool(s string) (bool, bool) {
+	switch s {
+	case "true", "True", "TRUE":
+		return true, true
+	case "false", "False", "FALSE":
+		return false, true
+	}
+	return false, false
+}
+
+var yamlTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// assign stores the node in v, converting scalars to the type of v.
+func (n *yamlNode) assign(v reflect.Value) error {
+	if n.isNull() {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return n.assign(v.Elem())
+	}
+	if n.kind == yamlScalar && v.CanAddr() && reflect.PointerTo(v.Type()).Implements(yamlTextUnmarshaler) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(n.value)); err != nil {
+			return n.errorf("%s", err)
+		}
+		return nil
+	}
+
+	mismatch := n.errorf("cannot decode %q into %s", n.value, v.Type())
+	switch n.kind {
+	case yamlMapping:
+		mismatch = n.errorf("cannot decode a mapping into %s", v.Type())
+	case yamlSequence:
+		mismatch = n.errorf("cannot decode a sequence into %s", v.Type())
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return mismatch
+		}
+		v.Set(reflect.ValueOf(n.generic()))
+	case reflect.String:
+		if n.kind != yamlScalar {
+			return mismatch
+		}
+		v.SetString(n.value)
+	case reflect.Bool:
+		b, ok := yamlBool(n.value)
+		if n.kind != yamlScalar || !ok {
+			return mismatch
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(n.value, 0, v.Type().Bits())
+		if n.kind != yamlScalar || err != nil {
+			return mismatch
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(n.value, 0, v.Type().Bits())
+		if n.kind != yamlScalar || err != nil {
+			return mismatch
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(n.value, v.Type().Bits())
+		if n.kind != yamlScalar || err != nil {
+			return mismatch
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if n.kind != yamlSequence {
+			return mismatch
+		}
+		s := reflect.MakeSlice(v.Type(), len(n.values), len(n.values))
+		for i, item := range n.values {
+			if err := item.assign(s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		if n.kind != yamlMapping || v.Type().Key().Kind() != reflect.String {
+			return mismatch
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(n.keys))
+		for i, key := range n.keys {
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := n.values[i].assign(value); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), value)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		if n.kind != yamlMapping {
+			return mismatch
+		}
+		for i, key := range n.keys {
+			// Unknown keys are ignored, as by encoding/json.
+			field, ok := yamlField(v, key)
+			if !ok {
+				continue
+			}
+			if err := n.values[i].assign(field); err != nil {
+				return err
+			}
+		}
+	default:
+		return mismatch
+	}
+	return nil
+}
+
+// yamlField finds the struct field named key by its yaml tag, its json tag
+// or, case-insensitively, its name.
+func yamlField(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag, ok := sf.Tag.Lookup("yaml")
+		if !ok {
+			tag = sf.Tag.Get("json")
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if name == key || (name == "" && strings.EqualFold(sf.Name, key)) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// generic converts the node for storage in an interface{}.
+func (n *yamlNode) generic() interface{} {
+	switch n.kind {
+	case yamlMapping:
+		m := make(map[string]interface{}, len(n.keys))
+		for i, key := range n.keys {
+			m[key] = n.values[i].generic()
+		}
+		return m
+	case yamlSequence:
+		s := make([]interface{}, len(n.values))
+		for i, item := range n.values {
+			s[i] = item.generic()
+		}
+		return s
+	}
+	return n.resolve()
+}